@@ -6,9 +6,15 @@ import "github.com/yuin/goldmark/ast"
 var KindBookmark = ast.NewNodeKind("Bookmark")
 
 // A Bookmark represents a bookmark in Notion.
+// Apart from the URL, the fields are only set if the metadata of the bookmarked page is known.
+// A caption may follow as child.
 type Bookmark struct {
 	ast.BaseInline
-	URL string
+	URL         string
+	Title       string
+	Description string
+	Favicon     string
+	Image       string
 }
 
 // Kind returns a kind of this node.
@@ -17,5 +23,11 @@ func (n *Bookmark) Kind() ast.NodeKind { return KindBookmark }
 // Dump dumps an AST tree structure to stdout.
 // This function completely aimed for debugging.
 func (n *Bookmark) Dump(source []byte, level int) {
-	ast.DumpHelper(n, source, level, map[string]string{"URL": n.URL}, nil)
+	ast.DumpHelper(n, source, level, map[string]string{
+		"URL":         n.URL,
+		"Title":       n.Title,
+		"Description": n.Description,
+		"Favicon":     n.Favicon,
+		"Image":       n.Image,
+	}, nil)
 }
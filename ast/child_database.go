@@ -10,6 +10,11 @@ var KindChildDatabase = ast.NewNodeKind("ChildDatabase")
 // A ChildDatabase represents a child database in Notion.
 type ChildDatabase struct {
 	ast.BaseInline
+	Title string
+
+	// Path is the URL escaped path of the database relative to the page,
+	// without a file extension.
+	Path string
 }
 
 // Kind returns a kind of this node.
@@ -18,5 +23,8 @@ func (n *ChildDatabase) Kind() ast.NodeKind { return KindChildDatabase }
 // Dump dumps an AST tree structure to stdout.
 // This function completely aimed for debugging.
 func (n *ChildDatabase) Dump(source []byte, level int) {
-	ast.DumpHelper(n, source, level, nil, nil)
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Title": n.Title,
+		"Path":  n.Path,
+	}, nil)
 }
@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// KindColumn is a ast.NodeKind of the Column node.
+var KindColumn = ast.NewNodeKind("Column")
+
+// A Column represents a column in Notion.
+type Column struct {
+	ast.BaseInline
+}
+
+// NewColumn returns a new column node.
+func NewColumn() ast.Node {
+	return &Column{}
+}
+
+// Kind returns a kind of this node.
+func (n *Column) Kind() ast.NodeKind { return KindColumn }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *Column) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// KindColumnList is a ast.NodeKind of the ColumnList node.
+var KindColumnList = ast.NewNodeKind("ColumnList")
+
+// A ColumnList represents a column list in Notion.
+type ColumnList struct {
+	ast.BaseInline
+}
+
+// NewColumnList returns a new column list node.
+func NewColumnList() ast.Node {
+	return &ColumnList{}
+}
+
+// Kind returns a kind of this node.
+func (n *ColumnList) Kind() ast.NodeKind { return KindColumnList }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *ColumnList) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
@@ -0,0 +1,30 @@
+package ast
+
+import (
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/yuin/goldmark/ast"
+)
+
+// KindDatabaseCard is a ast.NodeKind of the DatabaseCard node.
+var KindDatabaseCard = ast.NewNodeKind("DatabaseCard")
+
+// A DatabaseCard represents an entry of a database in a view other than a table.
+// A link to the entry and its DatabaseCardProperties follow as children.
+type DatabaseCard struct {
+	ast.BaseBlock
+	ID notion.UUID
+	// Cover is the destination of the cover image shown in galleries, if any.
+	Cover string
+}
+
+// Kind returns a kind of this node.
+func (n *DatabaseCard) Kind() ast.NodeKind { return KindDatabaseCard }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *DatabaseCard) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"ID":    string(n.ID),
+		"Cover": n.Cover,
+	}, nil)
+}
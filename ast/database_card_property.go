@@ -0,0 +1,21 @@
+package ast
+
+import "github.com/yuin/goldmark/ast"
+
+// KindDatabaseCardProperty is a ast.NodeKind of the DatabaseCardProperty node.
+var KindDatabaseCardProperty = ast.NewNodeKind("DatabaseCardProperty")
+
+// A DatabaseCardProperty represents the value of a visible property on a DatabaseCard.
+type DatabaseCardProperty struct {
+	ast.BaseInline
+	Name string
+}
+
+// Kind returns a kind of this node.
+func (n *DatabaseCardProperty) Kind() ast.NodeKind { return KindDatabaseCardProperty }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *DatabaseCardProperty) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.Name}, nil)
+}
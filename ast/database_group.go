@@ -0,0 +1,40 @@
+package ast
+
+import (
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/yuin/goldmark/ast"
+)
+
+// KindDatabaseGroup is a ast.NodeKind of the DatabaseGroup node.
+var KindDatabaseGroup = ast.NewNodeKind("DatabaseGroup")
+
+// A DatabaseGroup represents the entries of a database view that share a value,
+// e.g. a column of a board or a day of a calendar. Its children are DatabaseCards.
+type DatabaseGroup struct {
+	ast.BaseBlock
+	// Value is the option of the column of a board, if any.
+	Value *notion.SelectValue
+	// Day is the day of a calendar.
+	Day time.Time
+}
+
+// Kind returns a kind of this node.
+func (n *DatabaseGroup) Kind() ast.NodeKind { return KindDatabaseGroup }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *DatabaseGroup) Dump(source []byte, level int) {
+	kv := map[string]string{}
+
+	if n.Value != nil {
+		kv["Value"] = n.Value.Name
+	}
+
+	if !n.Day.IsZero() {
+		kv["Day"] = n.Day.Format("2006-01-02")
+	}
+
+	ast.DumpHelper(n, source, level, kv, nil)
+}
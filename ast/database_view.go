@@ -0,0 +1,41 @@
+package ast
+
+import "github.com/yuin/goldmark/ast"
+
+// DatabaseLayout is the layout of a DatabaseView.
+type DatabaseLayout string
+
+// Defines values for DatabaseLayout.
+const (
+	// DatabaseLayoutTable shows the entries as rows of a table, which is the only child of the view.
+	DatabaseLayoutTable DatabaseLayout = "table"
+	// DatabaseLayoutBoard shows the entries as cards in columns, one DatabaseGroup
+	// for every option of a select or status property.
+	DatabaseLayoutBoard DatabaseLayout = "board"
+	// DatabaseLayoutGallery shows the entries as cards with their cover.
+	DatabaseLayoutGallery DatabaseLayout = "gallery"
+	// DatabaseLayoutList shows the entries as a list of cards.
+	DatabaseLayoutList DatabaseLayout = "list"
+	// DatabaseLayoutCalendar shows the entries as cards bucketed by a date property,
+	// one DatabaseGroup for every day.
+	DatabaseLayoutCalendar DatabaseLayout = "calendar"
+)
+
+// KindDatabaseView is a ast.NodeKind of the DatabaseView node.
+var KindDatabaseView = ast.NewNodeKind("DatabaseView")
+
+// A DatabaseView represents the entries of a Notion database shown in a layout.
+// Its children depend on the layout, see DatabaseLayout.
+type DatabaseView struct {
+	ast.BaseBlock
+	Layout DatabaseLayout
+}
+
+// Kind returns a kind of this node.
+func (n *DatabaseView) Kind() ast.NodeKind { return KindDatabaseView }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *DatabaseView) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Layout": string(n.Layout)}, nil)
+}
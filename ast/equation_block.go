@@ -0,0 +1,22 @@
+package ast
+
+import "github.com/yuin/goldmark/ast"
+
+// KindEquationBlock is a ast.NodeKind of the EquationBlock node.
+var KindEquationBlock = ast.NewNodeKind("EquationBlock")
+
+// An EquationBlock represents an equation block in Notion,
+// which is displayed on its own instead of inline like an Equation.
+type EquationBlock struct {
+	ast.BaseInline
+	Expression string
+}
+
+// Kind returns a kind of this node.
+func (n *EquationBlock) Kind() ast.NodeKind { return KindEquationBlock }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *EquationBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Expression": n.Expression}, nil)
+}
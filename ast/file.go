@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"net/url"
+	"path"
 	"strconv"
 	"time"
 
@@ -13,9 +15,17 @@ import (
 var KindFile = ast.NewNodeKind("File")
 
 // A File represents a file in Notion.
+//
+// The link to the file is wrapped in an EmbedSource.
+// For images, the link contains the image, otherwise the text to display.
+// The caption, if any, is the last child.
 type File struct {
 	ast.BaseInline
-	Expires  time.Time
+	// Name is the name of the file, as taken from its URL.
+	Name string
+	// Expires is set for files uploaded to Notion.
+	Expires time.Time
+	// External is set for files that are not hosted by Notion.
 	External bool
 	tp       FileType
 }
@@ -30,57 +40,100 @@ const (
 	FileTypeImage FileType = "image"
 	// FileTypeVideo is a type for a file that is a video.
 	FileTypeVideo FileType = "video"
+	// FileTypeAudio is a type for a file that is an audio file.
+	FileTypeAudio FileType = "audio"
 	// FileTypeGeneric is a type for any other file.
 	FileTypeGeneric FileType = "generic"
 )
 
-// NewFile returns a new file node
+const (
+	// awsHost is the host name of files uploaded to notion.
+	awsHost = "s3.us-west-2.amazonaws.com"
+	// legacyHost is the host name notion displays for uploaded files.
+	legacyHost = "s3-us-west-2.amazonaws.com"
+)
+
+// NewFile returns a new file node.
+// The caption is not converted, add it as a Caption child.
 func NewFile(f notion.FileWithCaption, tp FileType) *File {
-	n := &File{}
+	n := &File{tp: tp}
+
+	rawURL := f.URL()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		u = &url.URL{Path: rawURL}
+	}
+
+	n.Name = path.Base(u.Path)
+
 	switch f.Type {
 	case notion.FileWithCaptionTypeExternal:
-		n.Expires = f.File.ExpiryTime
-	case notion.FileWithCaptionTypeFile:
 		n.External = true
+	case notion.FileWithCaptionTypeFile:
+		n.Expires = f.File.ExpiryTime
+
+		// notion displays uploaded files without the signature
+		u.RawQuery = ""
+
+		if u.Host == awsHost {
+			u.Host = legacyHost
+		}
 	}
 
 	link := ast.NewLink()
-	link.Destination = util.URLEscape([]byte(f.URL()), true)
+	link.Destination = util.URLEscape([]byte(rawURL), true)
 
 	if tp == FileTypeImage {
-		n.AppendChild(n, ast.NewImage(link))
+		link.AppendChild(link, ast.NewImage(link))
 	} else {
-		n.AppendChild(n, link)
+		link.AppendChild(link, ast.NewString([]byte(u.String())))
 	}
 
-	if f.Caption == nil || len(*f.Caption) == 0 {
-		return n
-	}
+	src := &EmbedSource{}
+	src.AppendChild(src, link)
+	n.AppendChild(n, src)
 
-	caption := &Caption{}
+	return n
+}
 
-	// TODO
-	// for _, child := range toNodeRichTexts(*f.Caption) {
-	// 	caption.AppendChild(caption, child)
-	// }
+// link returns the link to the file.
+func (n File) link() *ast.Link {
+	src := n.FirstChild()
+	if src == nil {
+		return nil
+	}
 
-	n.AppendChild(n, caption)
+	link, _ := src.FirstChild().(*ast.Link)
 
-	return n
+	return link
 }
 
 // Destination is a convenience method to return the destination of the underlying link.
 func (n File) Destination() []byte {
-	switch c := n.FirstChild().(type) {
-	case *ast.Link:
-		return c.Destination
-	case *ast.Image:
-		return c.Destination
-	default:
-		return nil
+	if link := n.link(); link != nil {
+		return link.Destination
 	}
+
+	return nil
 }
 
+// SetDestination sets the destination of the underlying link and, for images, of the image.
+func (n File) SetDestination(dest []byte) {
+	link := n.link()
+	if link == nil {
+		return
+	}
+
+	link.Destination = dest
+
+	if img, ok := link.FirstChild().(*ast.Image); ok {
+		img.Destination = dest
+	}
+}
+
+// FileType returns the type of this file.
+func (n File) FileType() FileType { return n.tp }
+
 // IsImage returns whether or not this file is an image.
 func (n File) IsImage() bool { return n.tp == FileTypeImage }
 
@@ -90,13 +143,21 @@ func (n File) IsPDF() bool { return n.tp == FileTypePDF }
 // IsVideo returns whether or not this file is a video.
 func (n File) IsVideo() bool { return n.tp == FileTypeVideo }
 
+// IsAudio returns whether or not this file is an audio file.
+func (n File) IsAudio() bool { return n.tp == FileTypeAudio }
+
 // Kind returns a kind of this node.
 func (n *File) Kind() ast.NodeKind { return KindFile }
 
 // Dump dumps an AST tree structure to stdout.
 // This function completely aimed for debugging.
 func (n *File) Dump(source []byte, level int) {
-	kv := map[string]string{"External": strconv.FormatBool(n.External)}
+	kv := map[string]string{
+		"Type":     string(n.tp),
+		"Name":     n.Name,
+		"External": strconv.FormatBool(n.External),
+	}
+
 	if !n.Expires.IsZero() {
 		kv["Expires"] = n.Expires.String()
 	}
@@ -10,6 +10,7 @@ var KindLinkPreview = ast.NewNodeKind("LinkPreview")
 // A LinkPreview represents a link preview in Notion.
 type LinkPreview struct {
 	ast.BaseInline
+	URL string
 }
 
 // Kind returns a kind of this node.
@@ -18,5 +19,5 @@ func (n *LinkPreview) Kind() ast.NodeKind { return KindLinkPreview }
 // Dump dumps an AST tree structure to stdout.
 // This function completely aimed for debugging.
 func (n *LinkPreview) Dump(source []byte, level int) {
-	ast.DumpHelper(n, source, level, nil, nil)
+	ast.DumpHelper(n, source, level, map[string]string{"URL": n.URL}, nil)
 }
@@ -0,0 +1,23 @@
+package ast
+
+import "github.com/yuin/goldmark/ast"
+
+// KindPageHeader is a ast.NodeKind of the PageHeader node.
+var KindPageHeader = ast.NewNodeKind("PageHeader")
+
+// A PageHeader represents the header of a Notion page with its cover, icon and title.
+// The icon, if any, and a heading with the title follow as children.
+type PageHeader struct {
+	ast.BaseInline
+	// Cover is the destination of the cover image, if any.
+	Cover string
+}
+
+// Kind returns a kind of this node.
+func (n *PageHeader) Kind() ast.NodeKind { return KindPageHeader }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *PageHeader) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Cover": n.Cover}, nil)
+}
@@ -1,6 +1,9 @@
 package ast
 
 import (
+	"strconv"
+
+	"github.com/faetools/go-notion/pkg/notion"
 	"github.com/yuin/goldmark/ast"
 )
 
@@ -10,11 +13,19 @@ var KindSyncedBlock = ast.NewNodeKind("SyncedBlock")
 // A SyncedBlock represents a syned block in Notion.
 type SyncedBlock struct {
 	ast.BaseInline
+	// SourceID is the ID of the original synced block, which has the content.
+	SourceID notion.UUID
+	// Copy is set if the block is a reference to the original synced block.
+	Copy bool
 }
 
 // NewSyncedBlock returns a new syned block node.
-func NewSyncedBlock() ast.Node {
-	return &SyncedBlock{}
+func NewSyncedBlock(id notion.UUID, s notion.SyncedBlock) *SyncedBlock {
+	if s.SyncedFrom != nil && s.SyncedFrom.BlockId != nil {
+		return &SyncedBlock{SourceID: *s.SyncedFrom.BlockId, Copy: true}
+	}
+
+	return &SyncedBlock{SourceID: id}
 }
 
 // Kind returns a kind of this node.
@@ -23,5 +34,8 @@ func (n *SyncedBlock) Kind() ast.NodeKind { return KindSyncedBlock }
 // Dump dumps an AST tree structure to stdout.
 // This function completely aimed for debugging.
 func (n *SyncedBlock) Dump(source []byte, level int) {
-	ast.DumpHelper(n, source, level, nil, nil)
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Source ID": string(n.SourceID),
+		"Copy":      strconv.FormatBool(n.Copy),
+	}, nil)
 }
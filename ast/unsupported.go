@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// KindUnsupported is a ast.NodeKind of the Unsupported node.
+var KindUnsupported = ast.NewNodeKind("Unsupported")
+
+// A Unsupported represents a block or rich text that could not be converted,
+// e.g. because Notion added a new type.
+// For rich texts, the plain text is added as a child.
+type Unsupported struct {
+	ast.BaseInline
+	// Block is set if a block is not supported, otherwise it is a rich text.
+	Block bool
+	// NotionType is the type of the block or rich text.
+	NotionType string
+}
+
+// NewUnsupported returns a new unsupported node.
+func NewUnsupported(block bool, tp string) *Unsupported {
+	return &Unsupported{Block: block, NotionType: tp}
+}
+
+// Kind returns a kind of this node.
+func (n *Unsupported) Kind() ast.NodeKind { return KindUnsupported }
+
+// Dump dumps an AST tree structure to stdout.
+// This function completely aimed for debugging.
+func (n *Unsupported) Dump(source []byte, level int) {
+	kv := map[string]string{"NotionType": n.NotionType}
+	if n.Block {
+		kv["Block"] = "true"
+	}
+
+	ast.DumpHelper(n, source, level, kv, nil)
+}
@@ -0,0 +1,424 @@
+// Package cache provides a notion.Getter that caches the responses of another notion.Getter.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/spf13/afero"
+)
+
+// Getter is a notion.Getter that caches the responses of another notion.Getter.
+// Concurrent requests for the same object are only sent once and are not canceled
+// until all callers waiting for them are.
+// The returned objects are shared and must not be modified.
+//
+// Pages, databases, database entries and the children of pages and blocks are kept in memory,
+// so they are only fetched once per Getter.
+//
+// If a file system is set, they are also stored in it, keyed by their ID and the time they were
+// last edited, and read from it by later Getters as long as that time has not changed:
+//
+//   - Pages and databases are read from the file system if their parent was fetched from Notion
+//     with the same Getter before, which lists when they were last edited, e.g. as child pages.
+//   - The entries of a database are always listed, since Notion does not update when a database
+//     was last edited if its entries change. They are stored as pages, though.
+//   - Since Notion updates when a page was last edited if anything within the page changes,
+//     the children of a nested block are considered changed whenever the page or any of the
+//     block's ancestors were edited. The children are only read from the file system if the page
+//     or block was fetched with the same Getter before, which the converter always does, but not
+//     for the original of a synced block on another page, since it is unknown when its page was edited.
+//
+// As Notion rounds the times to the minute, nothing edited within the last minute is stored.
+type Getter struct {
+	cli notion.Getter
+	fs  afero.Fs
+
+	mu     sync.Mutex
+	calls  map[string]*call
+	edited map[string]time.Time // by ID without dashes
+	listed map[string]time.Time // when pages and databases were last edited, by ID without dashes
+}
+
+// editedPrecision is the precision of the times Notion returns for when something was last edited.
+const editedPrecision = time.Minute
+
+// The directories the objects are stored in.
+const (
+	dirPages     = "pages"
+	dirDatabases = "databases"
+	dirBlocks    = "blocks"
+)
+
+type call struct {
+	done chan struct{}
+	val  any
+	err  error
+
+	// the callers waiting for the call, which is canceled if all of them are
+	waiting int
+	cancel  context.CancelFunc
+}
+
+// NewGetter returns a new Getter that caches the responses of cli.
+// If fs is not nil, the responses are also stored in fs.
+func NewGetter(cli notion.Getter, fs afero.Fs) *Getter {
+	return &Getter{
+		cli:    cli,
+		fs:     fs,
+		calls:  map[string]*call{},
+		edited: map[string]time.Time{},
+		listed: map[string]time.Time{},
+	}
+}
+
+// GetNotionPage implements notion.Getter.
+func (g *Getter) GetNotionPage(ctx context.Context, id notion.Id) (*notion.Page, error) {
+	v, err := g.do(ctx, "page/"+key(id), func(ctx context.Context) (any, error) {
+		edited, ok := g.getListed(key(id))
+
+		p := &notion.Page{}
+
+		found, err := g.read(dirPages, key(id), edited, ok, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			if p, err = g.cli.GetNotionPage(ctx, id); err != nil {
+				return nil, err
+			}
+
+			if err := g.write(dirPages, key(notion.Id(p.Id)), p.LastEditedTime, true, p); err != nil {
+				return nil, err
+			}
+		}
+
+		g.setEdited(key(notion.Id(p.Id)), p.LastEditedTime)
+
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*notion.Page), nil
+}
+
+// GetNotionDatabase implements notion.Getter.
+func (g *Getter) GetNotionDatabase(ctx context.Context, id notion.Id) (*notion.Database, error) {
+	v, err := g.do(ctx, "database/"+key(id), func(ctx context.Context) (any, error) {
+		edited, ok := g.getListed(key(id))
+
+		db := &notion.Database{}
+
+		found, err := g.read(dirDatabases, key(id), edited, ok, db)
+		if err != nil || found {
+			return db, err
+		}
+
+		if db, err = g.cli.GetNotionDatabase(ctx, id); err != nil {
+			return nil, err
+		}
+
+		return db, g.write(dirDatabases, key(notion.Id(db.Id)), db.LastEditedTime, true, db)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*notion.Database), nil
+}
+
+// GetAllDatabaseEntries implements notion.Getter.
+// The entries are cached as pages as well.
+func (g *Getter) GetAllDatabaseEntries(ctx context.Context, id notion.Id) (notion.Pages, error) {
+	v, err := g.do(ctx, "entries/"+key(id), func(ctx context.Context) (any, error) {
+		entries, err := g.cli.GetAllDatabaseEntries(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return entries, g.addEntries(entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(notion.Pages), nil
+}
+
+// GetDatabaseEntries returns the filtered and sorted entries of a database,
+// if the underlying notion.Getter can filter and sort them like notion.Client.
+// The entries are cached as pages as well.
+func (g *Getter) GetDatabaseEntries(ctx context.Context, id notion.Id, filter *notion.Filter, sorts *notion.Sorts) (notion.Pages, error) {
+	cli, ok := g.cli.(interface {
+		GetDatabaseEntries(context.Context, notion.Id, *notion.Filter, *notion.Sorts) (notion.Pages, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("%T cannot filter or sort database entries", g.cli)
+	}
+
+	query, err := json.Marshal(struct {
+		Filter *notion.Filter `json:"filter"`
+		Sorts  *notion.Sorts  `json:"sorts"`
+	}{filter, sorts})
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := g.do(ctx, "entries/"+key(id)+"/"+string(query), func(ctx context.Context) (any, error) {
+		entries, err := cli.GetDatabaseEntries(ctx, id, filter, sorts)
+		if err != nil {
+			return nil, err
+		}
+
+		return entries, g.addEntries(entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(notion.Pages), nil
+}
+
+// addEntries caches the entries of a database as pages.
+func (g *Getter) addEntries(entries notion.Pages) error {
+	for i := range entries {
+		p := &entries[i]
+		g.setEdited(key(notion.Id(p.Id)), p.LastEditedTime)
+		g.setListed(key(notion.Id(p.Id)), p.LastEditedTime)
+		g.done("page/"+key(notion.Id(p.Id)), p)
+
+		if err := g.write(dirPages, key(notion.Id(p.Id)), p.LastEditedTime, true, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAllBlocks implements notion.Getter.
+func (g *Getter) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	v, err := g.do(ctx, "blocks/"+key(id), func(ctx context.Context) (any, error) {
+		edited, ok := g.getEdited(key(id))
+
+		var blocks notion.Blocks
+
+		found, err := g.read(dirBlocks, key(id), edited, ok, &blocks)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			if blocks, err = g.cli.GetAllBlocks(ctx, id); err != nil {
+				return nil, err
+			}
+
+			if err := g.write(dirBlocks, key(id), edited, ok, blocks); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, b := range blocks {
+			// child pages and databases are listed with the time they were last edited,
+			// unless the blocks are outdated
+			if !found && (b.Type == notion.BlockTypeChildPage || b.Type == notion.BlockTypeChildDatabase) {
+				g.setListed(key(notion.Id(b.Id)), b.LastEditedTime)
+			}
+
+			// without knowing when an ancestor was edited, the children could have changed anytime
+			if !ok || !b.HasChildren {
+				continue
+			}
+
+			// the children change if an ancestor was edited
+			t := b.LastEditedTime
+			if edited.After(t) {
+				t = edited
+			}
+
+			g.setEdited(key(notion.Id(b.Id)), t)
+		}
+
+		return blocks, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(notion.Blocks), nil
+}
+
+// do calls fn once for the key and returns its result to all callers.
+// The call is only canceled if all callers are, so it does not fail for the others.
+// Failed calls are not cached.
+func (g *Getter) do(ctx context.Context, key string, fn func(context.Context) (any, error)) (any, error) {
+	g.mu.Lock()
+
+	c, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(detached{ctx})
+
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+
+		go func() {
+			defer cancel()
+
+			c.val, c.err = fn(callCtx)
+			if c.err != nil {
+				g.forget(key, c)
+			}
+
+			close(c.done)
+		}()
+	}
+
+	c.waiting++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiting--
+
+		if c.waiting == 0 && c.cancel != nil {
+			c.cancel()
+			delete(g.calls, key)
+		}
+
+		g.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes the call for the key, unless it has been replaced already.
+func (g *Getter) forget(key string, c *call) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// detached is a context with the values of another context, which is never canceled.
+type detached struct{ ctx context.Context }
+
+func (detached) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detached) Done() <-chan struct{}               { return nil }
+func (detached) Err() error                          { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.ctx.Value(key) }
+
+// done caches the value for the key unless it has been requested already.
+func (g *Getter) done(key string, val any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.calls[key]; ok {
+		return
+	}
+
+	c := &call{done: make(chan struct{}), val: val}
+	close(c.done)
+	g.calls[key] = c
+}
+
+func (g *Getter) getEdited(id string) (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t, ok := g.edited[id]
+
+	return t, ok
+}
+
+func (g *Getter) setEdited(id string, t time.Time) {
+	g.mu.Lock()
+	g.edited[id] = t
+	g.mu.Unlock()
+}
+
+func (g *Getter) getListed(id string) (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t, ok := g.listed[id]
+
+	return t, ok
+}
+
+func (g *Getter) setListed(id string, t time.Time) {
+	g.mu.Lock()
+	g.listed[id] = t
+	g.mu.Unlock()
+}
+
+// storedPath returns the path an object is stored at.
+func storedPath(dir, id string, edited time.Time) string {
+	return path.Join(dir, id, strconv.FormatInt(edited.UnixNano(), 10)+".json")
+}
+
+// stored reports whether an object edited at the time is stored at all.
+// Later edits within the same minute would not change the time.
+func (g *Getter) stored(edited time.Time, ok bool) bool {
+	return g.fs != nil && ok && time.Since(edited) >= editedPrecision
+}
+
+// read decodes the stored object into v and reports whether it was found.
+func (g *Getter) read(dir, id string, edited time.Time, ok bool, v any) (bool, error) {
+	if !g.stored(edited, ok) {
+		return false, nil
+	}
+
+	b, err := afero.ReadFile(g.fs, storedPath(dir, id, edited))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(b, v)
+}
+
+// write stores the object, replacing older versions.
+func (g *Getter) write(dir, id string, edited time.Time, ok bool, v any) error {
+	if !g.stored(edited, ok) {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := g.fs.RemoveAll(path.Join(dir, id)); err != nil {
+		return err
+	}
+
+	if err := g.fs.MkdirAll(path.Join(dir, id), 0o755); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(g.fs, storedPath(dir, id, edited), b, 0o644)
+}
+
+// key returns the ID in a consistent format, since it can be given with or without dashes.
+func key(id notion.Id) string {
+	return strings.ToLower(strings.ReplaceAll(string(id), "-", ""))
+}
@@ -0,0 +1,354 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/cache"
+	"github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	childPageID = "2633808e-7e36-4f4e-972a-ccd2d3c49004"
+	databaseID  = "7a3c647e-4c1e-4c27-bf1d-cfb0105e55ce"
+)
+
+// countingGetter counts the requests to the underlying getter.
+type countingGetter struct {
+	notion.Getter
+
+	mu     sync.Mutex
+	counts map[string]int
+	edited time.Time
+}
+
+func newCountingGetter(t *testing.T) *countingGetter {
+	t.Helper()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	return &countingGetter{Getter: cli, counts: map[string]int{}}
+}
+
+func (g *countingGetter) count(key string) {
+	g.mu.Lock()
+	g.counts[key]++
+	g.mu.Unlock()
+}
+
+func (g *countingGetter) total() (n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, c := range g.counts {
+		n += c
+	}
+
+	return n
+}
+
+func (g *countingGetter) GetNotionPage(ctx context.Context, id notion.Id) (*notion.Page, error) {
+	g.count("page/" + string(id))
+
+	p, err := g.Getter.GetNotionPage(ctx, id)
+	if err == nil && !g.edited.IsZero() {
+		p.LastEditedTime = g.edited
+	}
+
+	return p, err
+}
+
+func (g *countingGetter) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	g.count("blocks/" + string(id))
+
+	// give concurrent requests time to arrive
+	time.Sleep(5 * time.Millisecond)
+
+	return g.Getter.GetAllBlocks(ctx, id)
+}
+
+func (g *countingGetter) GetNotionDatabase(ctx context.Context, id notion.Id) (*notion.Database, error) {
+	g.count("database/" + string(id))
+	return g.Getter.GetNotionDatabase(ctx, id)
+}
+
+func (g *countingGetter) GetAllDatabaseEntries(ctx context.Context, id notion.Id) (notion.Pages, error) {
+	g.count("entries/" + string(id))
+	return g.Getter.GetAllDatabaseEntries(ctx, id)
+}
+
+func TestGetter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli := newCountingGetter(t)
+	g := cache.NewGetter(cli, nil)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			blocks, err := g.GetAllBlocks(ctx, fake.PageID)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, blocks)
+		}()
+	}
+
+	wg.Wait()
+
+	// the ID can also be given without dashes
+	_, err := g.GetAllBlocks(ctx, "96245c8f178444a482ad1941127c3ec3")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cli.counts["blocks/"+string(fake.PageID)])
+
+	// the entries are cached as pages
+	entries, err := g.GetAllDatabaseEntries(ctx, databaseID)
+	assert.NoError(t, err)
+
+	for _, e := range entries {
+		p, err := g.GetNotionPage(ctx, notion.Id(e.Id))
+		assert.NoError(t, err)
+		assert.Equal(t, e.Id, p.Id)
+	}
+
+	assert.Equal(t, 2, cli.total())
+
+	// failed requests are not cached
+	_, err = g.GetNotionPage(ctx, "missing")
+	assert.Error(t, err)
+	_, err = g.GetNotionPage(ctx, "missing")
+	assert.Error(t, err)
+	assert.Equal(t, 2, cli.counts["page/missing"])
+}
+
+func TestGetter_FS(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+
+	convert := func(cli notion.Getter) {
+		t.Helper()
+
+		// the fake client does not have the second child database
+		_, _, err := goldmark.NewConverter(cache.NewGetter(cli, fs),
+			goldmark.WithMaxBlocks(57),
+			goldmark.WithUnsupportedPolicy(goldmark.UnsupportedSkip)).Page(ctx, fake.PageID)
+		assert.NoError(t, err)
+	}
+
+	first := newCountingGetter(t)
+	convert(first)
+	assert.Equal(t, 1, first.counts["blocks/"+string(fake.PageID)])
+
+	// the page has not changed, so the blocks are read from the file system
+	second := newCountingGetter(t)
+	convert(second)
+	assert.Equal(t, 1, second.counts["page/"+string(fake.PageID)])
+	assert.Zero(t, second.counts["blocks/"+string(fake.PageID)])
+
+	// the page has changed, so all blocks are fetched again,
+	// but they list the database as unchanged, so it is read from the file system
+	want := map[string]int{}
+	for k, n := range first.counts {
+		want[k] = n
+	}
+
+	delete(want, "database/"+databaseID)
+
+	third := newCountingGetter(t)
+	third.edited = time.Now()
+	convert(third)
+	assert.Equal(t, want, third.counts)
+
+	// Notion rounds the time to the minute, so recently edited pages are not read from the file system
+	fourth := newCountingGetter(t)
+	fourth.edited = third.edited
+	convert(fourth)
+	assert.Equal(t, want, fourth.counts)
+}
+
+func TestGetter_FS_Pages(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+
+	getChildPage := func(cli notion.Getter) {
+		t.Helper()
+
+		g := cache.NewGetter(cli, fs)
+
+		// the children of the page list when the child page was last edited
+		_, err := g.GetAllBlocks(ctx, fake.PageID)
+		assert.NoError(t, err)
+
+		p, err := g.GetNotionPage(ctx, childPageID)
+		assert.NoError(t, err)
+		assert.Equal(t, "My child page", p.Title())
+	}
+
+	first := newCountingGetter(t)
+	getChildPage(first)
+	assert.Equal(t, 1, first.counts["page/"+childPageID])
+
+	second := newCountingGetter(t)
+	getChildPage(second)
+	assert.Zero(t, second.counts["page/"+childPageID])
+
+	// without knowing when the page was last edited, it is fetched
+	third := newCountingGetter(t)
+	_, err := cache.NewGetter(third, fs).GetNotionPage(ctx, childPageID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, third.counts["page/"+childPageID])
+}
+
+// blockingGetter returns pages once it is released.
+type blockingGetter struct {
+	*countingGetter
+	release chan struct{}
+}
+
+func (g blockingGetter) GetNotionPage(ctx context.Context, id notion.Id) (*notion.Page, error) {
+	select {
+	case <-g.release:
+		return g.countingGetter.GetNotionPage(ctx, id)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestGetter_Canceled(t *testing.T) {
+	t.Parallel()
+
+	cli := blockingGetter{newCountingGetter(t), make(chan struct{})}
+	g := cache.NewGetter(cli, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		_, err := g.GetNotionPage(ctx, fake.PageID)
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		// the request is shared, but not canceled with the other caller
+		p, err := g.GetNotionPage(context.Background(), fake.PageID)
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(cli.release)
+
+	wg.Wait()
+	assert.Equal(t, 1, cli.counts["page/"+string(fake.PageID)])
+
+	// the request is canceled once all callers are, and sent again for the next caller
+	cli = blockingGetter{newCountingGetter(t), make(chan struct{})}
+	g = cache.NewGetter(cli, nil)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err := g.GetNotionPage(ctx, fake.PageID)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(cli.release)
+
+	_, err = g.GetNotionPage(context.Background(), fake.PageID)
+	assert.NoError(t, err)
+}
+
+func TestGetter_FS_UnknownAncestors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+
+	// like the original of a synced block on another page, the page is not fetched
+	getChildren := func(cli notion.Getter) {
+		t.Helper()
+
+		g := cache.NewGetter(cli, fs)
+
+		blocks, err := g.GetAllBlocks(ctx, fake.PageID)
+		assert.NoError(t, err)
+
+		for _, b := range blocks {
+			if b.HasChildren {
+				_, err := g.GetAllBlocks(ctx, notion.Id(b.Id))
+				assert.NoError(t, err)
+			}
+		}
+	}
+
+	first := newCountingGetter(t)
+	getChildren(first)
+
+	second := newCountingGetter(t)
+	getChildren(second)
+	assert.Equal(t, first.counts, second.counts)
+}
+
+// entriesGetter counts the requests for filtered and sorted database entries.
+type entriesGetter struct {
+	*countingGetter
+}
+
+func (g entriesGetter) GetDatabaseEntries(ctx context.Context, id notion.Id, filter *notion.Filter, sorts *notion.Sorts) (notion.Pages, error) {
+	g.count("query/" + string(id))
+	return g.Getter.(*notion.Client).GetDatabaseEntries(ctx, id, filter, sorts)
+}
+
+func TestGetter_GetDatabaseEntries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	id := notion.Id(databaseID)
+	asc := &notion.Sorts{{Property: "Name", Direction: notion.SortDirectionAscending}}
+	desc := &notion.Sorts{{Property: "Name", Direction: notion.SortDirectionDescending}}
+
+	cli := entriesGetter{newCountingGetter(t)}
+	g := cache.NewGetter(cli, nil)
+
+	for _, sorts := range []*notion.Sorts{asc, asc, desc} {
+		entries, err := g.GetDatabaseEntries(ctx, id, nil, sorts)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 4)
+	}
+
+	// the entries are cached per query
+	assert.Equal(t, 2, cli.counts["query/"+string(id)])
+
+	// and as pages
+	_, err := g.GetNotionPage(ctx, "20c5eed2-59ea-476e-af69-da0ae68a084d")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cli.total())
+
+	// getters that cannot filter or sort database entries
+	_, err = cache.NewGetter(newCountingGetter(t), nil).GetDatabaseEntries(ctx, id, nil, asc)
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+// Command notion2md exports a Notion page or database with all its descendants.
+//
+// Usage:
+//
+//	NOTION_TOKEN=secret_... notion2md [flags] <page or database ID>
+//
+// The flags are:
+//
+//	--out dir
+//		The directory to export to. The default is the current directory.
+//	--format html|md|json
+//		How the pages are exported. HTML and Markdown follow the layout of Notion's own export.
+//		JSON writes the responses of the Notion API, which can be used with --fixtures later.
+//		The default is md.
+//	--fixtures dir
+//		Read the responses of the Notion API from a directory laid out like go-notion's fake
+//		package, e.g. "v1/pages/<id>.json", instead of using the API. NOTION_TOKEN is not needed then.
+//	--incremental
+//		Only export what changed since the previous export to the same directory.
+//
+// To preview pages as HTML while editing them, serve them instead:
+//
+//	NOTION_TOKEN=secret_... notion2md serve [--addr localhost:8080] [--fixtures dir] [<page or database ID>]
+//
+// Every page and database is then shown at http://localhost:8080/<ID>,
+// and the page or database that was passed is shown at http://localhost:8080/.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/faetools/client"
+	notionclient "github.com/faetools/go-notion/pkg/client"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/docs"
+	"github.com/faetools/notion-to-goldmark/export"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Getenv, http.DefaultClient, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "notion2md:", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+// run runs the command with the arguments and environment variables.
+// The HTTP client sends the requests to the Notion API and downloads the assets.
+func run(ctx context.Context, args []string, getenv func(string) string, httpClient *http.Client, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "serve" {
+		return runServe(ctx, args[1:], getenv, httpClient, stderr)
+	}
+
+	flags := flag.NewFlagSet("notion2md", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: NOTION_TOKEN=secret_... notion2md [flags] <page or database ID>")
+		flags.PrintDefaults()
+	}
+
+	out := flags.String("out", ".", "the directory to export to")
+	format := flags.String("format", "md", "how the pages are exported: html, md or json")
+	fixtures := flags.String("fixtures", "", "a directory with responses of the Notion API to use instead of the API")
+	incremental := flags.Bool("incremental", false, "only export what changed since the previous export")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected exactly one page or database ID")
+	}
+
+	id := notion.Id(flags.Arg(0))
+
+	doer, token, err := newDoer(*fixtures, getenv, httpClient)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	fs := afero.NewBasePathFs(afero.NewOsFs(), *out)
+
+	opts := []export.Option{export.WithHTTPClient(httpClient)}
+
+	switch *format {
+	case "json":
+		return record(ctx, doer, token, fs, id)
+	case "html":
+		opts = append(opts, export.WithFormat(export.HTML()))
+	case "md":
+		opts = append(opts, export.WithFormat(export.Markdown()))
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	if *incremental {
+		opts = append(opts, export.WithIncremental())
+	}
+
+	cli, err := notion.NewDefaultClient(token, client.WithHTTPClient(doer))
+	if err != nil {
+		return err
+	}
+
+	tp, err := objectType(ctx, cli, id)
+	if err != nil {
+		return err
+	}
+
+	e := export.NewExporter(cli, fs, opts...)
+
+	var m *export.Manifest
+
+	if tp == docs.TypeDatabase {
+		m, err = e.ExportDatabase(ctx, id)
+	} else {
+		m, err = e.Export(ctx, id)
+	}
+
+	// the pages were exported anyway, linking to Notion instead
+	var assetErrs n_goldmark.AssetErrors
+	if errors.As(err, &assetErrs) {
+		for _, err := range assetErrs {
+			fmt.Fprintln(stderr, "warning:", err)
+		}
+
+		err = nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stderr, "exported %d pages and databases to %s\n", len(m.Files), *out)
+
+	return nil
+}
+
+// newDoer returns the client that sends the requests to the Notion API, or reads them from the fixtures,
+// together with the token to authenticate them with.
+func newDoer(fixtures string, getenv func(string) string, httpClient *http.Client) (client.HTTPRequestDoer, string, error) {
+	if fixtures != "" {
+		doer, err := notionclient.NewFSClient(os.DirFS(fixtures), notFound)
+		return doer, "", err
+	}
+
+	token := getenv("NOTION_TOKEN")
+	if token == "" {
+		return nil, "", errors.New("NOTION_TOKEN is not set")
+	}
+
+	return httpClient, token, nil
+}
+
+// notFound is the response for requests without a fixture, like the one of the Notion API.
+func notFound(path string) any {
+	return notion.ErrorResponse{
+		Code:    "object_not_found",
+		Message: fmt.Sprintf("no response found for %s", path),
+		Object:  "error",
+		Status:  http.StatusNotFound,
+	}
+}
+
+// objectType returns whether the ID is of a page or a database.
+func objectType(ctx context.Context, cli notion.Getter, id notion.Id) (docs.Type, error) {
+	_, err := cli.GetNotionPage(ctx, id)
+	if n_goldmark.IsNotFound(err) {
+		return docs.TypeDatabase, nil
+	}
+
+	return docs.TypePage, err
+}
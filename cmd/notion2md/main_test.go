@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faetools/client"
+	notionclient "github.com/faetools/go-notion/pkg/client"
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	childPageID  = "2633808e-7e36-4f4e-972a-ccd2d3c49004"
+	databaseID   = "7a3c647e-4c1e-4c27-bf1d-cfb0105e55ce"
+	unknownID    = "00000000-0000-0000-0000-000000000000"
+	childPage    = "My child page 2633808e7e364f4e972accd2d3c49004"
+	databaseName = "My Child Database 7a3c647e4c1e4c27bf1dcfb0105e55ce"
+)
+
+var httpClient = &http.Client{Transport: roundTripper(func(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+})}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return rt(req) }
+
+// fixtures records the fake responses for the page or database into a directory.
+func fixtures(t *testing.T, id notion.Id) string {
+	t.Helper()
+
+	_, doer, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	assert.NoError(t, record(context.Background(), doer, "", afero.NewBasePathFs(afero.NewOsFs(), dir), id))
+
+	return dir
+}
+
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths := []string{}
+	assert.NoError(t, filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, err := filepath.Rel(dir, p)
+			paths = append(paths, filepath.ToSlash(rel))
+
+			return err
+		}
+
+		return err
+	}))
+
+	return paths
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	noEnv := func(string) string { return "" }
+
+	t.Run("markdown", func(t *testing.T) {
+		t.Parallel()
+
+		in, out := fixtures(t, childPageID), t.TempDir()
+		stderr := &bytes.Buffer{}
+
+		assert.NoError(t, run(ctx, []string{"--fixtures", in, "--out", out, childPageID}, noEnv, httpClient, stderr))
+		assert.Contains(t, stderr.String(), "exported 1 pages and databases")
+
+		assert.ElementsMatch(t, []string{childPage + ".md", "manifest.json"}, files(t, out))
+
+		b, err := os.ReadFile(filepath.Join(out, childPage+".md"))
+		assert.NoError(t, err)
+		assert.Equal(t, "with some content\n", string(b))
+	})
+
+	t.Run("html database", func(t *testing.T) {
+		t.Parallel()
+
+		in, out := fixtures(t, databaseID), t.TempDir()
+
+		assert.NoError(t, run(ctx, []string{
+			"--fixtures", in, "--out", out, "--format", "html", databaseID,
+		}, noEnv, httpClient, &bytes.Buffer{}))
+
+		got := files(t, out)
+		assert.Contains(t, got, databaseName+".html")
+		assert.Contains(t, got, databaseName+"/entry 1 20c5eed259ea476eaf69da0ae68a084d.html")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		in, out := fixtures(t, databaseID), t.TempDir()
+
+		assert.NoError(t, run(ctx, []string{
+			"--fixtures", in, "--out", out, "--format", "json", databaseID,
+		}, noEnv, httpClient, &bytes.Buffer{}))
+
+		// recording the recorded responses results in the same responses
+		assert.ElementsMatch(t, files(t, in), files(t, out))
+		assert.Contains(t, files(t, out), "v1/databases/"+databaseID+".json")
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		stderr := &bytes.Buffer{}
+		assert.NoError(t, run(ctx, []string{
+			"serve", "--fixtures", fixtures(t, childPageID), "--addr", "127.0.0.1:0", childPageID,
+		}, noEnv, httpClient, stderr))
+		assert.Contains(t, stderr.String(), "serving pages at http://127.0.0.1:")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		in := fixtures(t, childPageID)
+
+		for name, args := range map[string][]string{
+			"no ID":          {"--fixtures", in},
+			"unknown format": {"--fixtures", in, "--format", "pdf", "--out", t.TempDir(), childPageID},
+			"unknown ID":     {"--fixtures", in, "--out", t.TempDir(), unknownID},
+			"no token":       {childPageID},
+		} {
+			assert.Error(t, run(ctx, args, noEnv, httpClient, &bytes.Buffer{}), name)
+		}
+	})
+}
+
+func TestRecord_Pagination(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	result := func(req *http.Request, id string) string {
+		if strings.HasPrefix(req.URL.Path, "/v1/databases/") {
+			return `{"object":"page","id":"` + id + `","properties":{}}`
+		}
+
+		return `{"object":"block","id":"` + id + `","type":"paragraph","paragraph":{"rich_text":[]}}`
+	}
+
+	// the Notion API returns the blocks of the page and the entries of the database in two pages each
+	api := &http.Client{Transport: roundTripper(func(req *http.Request) (*http.Response, error) {
+		body := `{"object":"list","results":[` + result(req, childPageID) + `],"has_more":true,"next_cursor":"` + unknownID + `"}`
+
+		next, err := hasStartCursor(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if next {
+			body = `{"object":"list","results":[` + result(req, databaseID) + `],"has_more":false,"next_cursor":null}`
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{client.ContentType: {client.MIMEApplicationJSON}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+
+	fs := afero.NewMemMapFs()
+
+	cli, err := notion.NewDefaultClient("", client.WithHTTPClient(&recorder{doer: api, fs: fs}))
+	assert.NoError(t, err)
+
+	blocks, err := cli.GetAllBlocks(ctx, childPageID)
+	assert.NoError(t, err)
+	assert.Len(t, blocks, 2)
+
+	entries, err := cli.GetAllDatabaseEntries(ctx, databaseID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// the fixtures return all results at once
+	doer, err := notionclient.NewFSClient(afero.NewIOFS(fs), notFound)
+	assert.NoError(t, err)
+
+	cli, err = notion.NewDefaultClient("", client.WithHTTPClient(doer))
+	assert.NoError(t, err)
+
+	got, err := cli.GetAllBlocks(ctx, childPageID)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks, got)
+
+	gotEntries, err := cli.GetAllDatabaseEntries(ctx, databaseID)
+	assert.NoError(t, err)
+	assert.Len(t, gotEntries, 2)
+}
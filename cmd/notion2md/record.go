@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/faetools/client"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/docs"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+)
+
+// record writes the responses of the Notion API for the page or database and all its descendants
+// to fs, laid out like go-notion's fake package.
+func record(ctx context.Context, doer client.HTTPRequestDoer, token string, fs afero.Fs, id notion.Id) error {
+	cli, err := notion.NewDefaultClient(token, client.WithHTTPClient(&recorder{doer: doer, fs: fs}))
+	if err != nil {
+		return err
+	}
+
+	tp, err := objectType(ctx, cli, id)
+	if err != nil {
+		return err
+	}
+
+	return docs.Walk(ctx, recordWalker{cli}, tp, id)
+}
+
+// recorder writes the successful responses to fs, e.g. to "v1/pages/<id>.json".
+// Paginated responses are merged into one file, since the fixtures are looked up by path alone.
+type recorder struct {
+	doer client.HTTPRequestDoer
+	fs   afero.Fs
+}
+
+// Do implements client.HTTPRequestDoer.
+func (r *recorder) Do(req *http.Request) (*http.Response, error) {
+	next, err := hasStartCursor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.doer.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	name := req.URL.Path[1:] + ".json"
+	if err := r.fs.MkdirAll(path.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+
+	merged, err := r.merge(name, body, next)
+	if err != nil {
+		return nil, fmt.Errorf("recording %s: %w", req.URL.Path, err)
+	}
+
+	return resp, afero.WriteFile(r.fs, name, merged, 0o644)
+}
+
+// merge returns the response to write to the file, which has all results of a paginated response so far
+// and no further pages, so that the fixtures are not asked for them.
+func (r *recorder) merge(name string, body []byte, next bool) ([]byte, error) {
+	page := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+
+	if string(page["object"]) != `"list"` {
+		return body, nil
+	}
+
+	results := []json.RawMessage{}
+
+	if next {
+		prev, err := afero.ReadFile(r.fs, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var list struct{ Results []json.RawMessage }
+		if err := json.Unmarshal(prev, &list); err != nil {
+			return nil, err
+		}
+
+		results = append(results, list.Results...)
+	}
+
+	var more []json.RawMessage
+	if err := json.Unmarshal(page["results"], &more); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(append(results, more...))
+	if err != nil {
+		return nil, err
+	}
+
+	page["results"] = b
+	page["has_more"] = json.RawMessage("false")
+	page["next_cursor"] = json.RawMessage("null")
+
+	return json.Marshal(page)
+}
+
+// hasStartCursor reports whether the request asks for a further page of a paginated response,
+// either in its query or, for database queries, in its body.
+func hasStartCursor(req *http.Request) (bool, error) {
+	if req.URL.Query().Get("start_cursor") != "" {
+		return true, nil
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return false, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return false, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var params struct {
+		StartCursor *string `json:"start_cursor"`
+	}
+
+	if err := json.Unmarshal(body, &params); err != nil {
+		// not a request of the Notion API with a cursor
+		return false, nil
+	}
+
+	return params.StartCursor != nil && *params.StartCursor != "", nil
+}
+
+// recordWalker walks through everything that is exported.
+type recordWalker struct{ notion.Getter }
+
+// GetNotionDatabase implements notion.Getter.
+// Databases that cannot be found, e.g. because they are not shared with the integration, are skipped.
+func (w recordWalker) GetNotionDatabase(ctx context.Context, id notion.Id) (*notion.Database, error) {
+	db, err := w.Getter.GetNotionDatabase(ctx, id)
+	if n_goldmark.IsNotFound(err) {
+		return nil, docs.Skip
+	}
+
+	return db, err
+}
+
+// VisitPage implements docs.GetterVisitor.
+func (recordWalker) VisitPage(*notion.Page) error { return nil }
+
+// VisitBlock implements docs.GetterVisitor.
+func (recordWalker) VisitBlock(notion.Block) error { return nil }
+
+// VisitDatabase implements docs.GetterVisitor.
+func (recordWalker) VisitDatabase(*notion.Database) error { return nil }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/faetools/client"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/serve"
+)
+
+// runServe runs the serve subcommand, which previews pages until ctx is done.
+func runServe(ctx context.Context, args []string, getenv func(string) string, httpClient *http.Client, stderr io.Writer) error {
+	flags := flag.NewFlagSet("notion2md serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: NOTION_TOKEN=secret_... notion2md serve [flags] [<page or database ID>]")
+		flags.PrintDefaults()
+	}
+
+	addr := flags.String("addr", "localhost:8080", "the address to listen on")
+	fixtures := flags.String("fixtures", "", "a directory with responses of the Notion API to use instead of the API")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("expected at most one page or database ID")
+	}
+
+	doer, token, err := newDoer(*fixtures, getenv, httpClient)
+	if err != nil {
+		return err
+	}
+
+	cli, err := notion.NewDefaultClient(token, client.WithHTTPClient(doer))
+	if err != nil {
+		return err
+	}
+
+	var h http.Handler = serve.NewHandler(cli, serve.WithHTTPClient(httpClient))
+
+	// the page or database is shown at the root
+	if id := flags.Arg(0); id != "" {
+		pages := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				http.Redirect(w, r, "/"+id, http.StatusFound)
+				return
+			}
+
+			pages.ServeHTTP(w, r)
+		})
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stderr, "serving pages at http://%s/<page or database ID>\n", l.Addr())
+
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
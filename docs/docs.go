@@ -9,6 +9,9 @@ import (
 	"github.com/faetools/go-notion/pkg/notion"
 )
 
+// Type defines the type of document.
+type Type = docs.Type
+
 // Defines values for Type.
 const (
 	TypePage     = docs.TypePage
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+)
+
+// assetStore remembers which assets were stored.
+type assetStore struct {
+	store n_goldmark.AssetStore
+
+	mu    sync.Mutex
+	paths []string
+}
+
+// Store implements n_goldmark.AssetStore.
+func (s *assetStore) Store(ctx context.Context, dir, rawURL string) (string, error) {
+	p, err := s.store.Store(ctx, dir, rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	s.add(p)
+
+	return p, nil
+}
+
+// add remembers that the file at p was stored.
+func (s *assetStore) add(p string) {
+	s.mu.Lock()
+	s.paths = append(s.paths, filepath.ToSlash(p))
+	s.mu.Unlock()
+}
+
+// stored returns the paths of the stored assets within dir, without duplicates.
+func (s *assetStore) stored(dir string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := map[string]bool{}
+
+	var paths []string
+
+	for _, p := range s.paths {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, path.Join(dir, p))
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// fileRecorder remembers the files created in its file system, e.g. the CSV files of
+// child databases, as assets, so they are moved and removed along with the page.
+type fileRecorder struct {
+	afero.Fs
+	assets *assetStore
+}
+
+// Create implements afero.Fs.
+func (r *fileRecorder) Create(name string) (afero.File, error) {
+	return r.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
+}
+
+// OpenFile implements afero.Fs.
+func (r *fileRecorder) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := r.Fs.OpenFile(name, flag, perm)
+	if err == nil && flag&os.O_CREATE != 0 {
+		r.assets.add(name)
+	}
+
+	return f, err
+}
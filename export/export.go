@@ -0,0 +1,404 @@
+// Package export exports Notion pages with all their descendants into a file system,
+// laid out like Notion's own export.
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/cache"
+	"github.com/faetools/notion-to-goldmark/docs"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithFormat sets how the pages are rendered. The default is HTML().
+func WithFormat(f Format) Option {
+	return func(e *Exporter) { e.format = f }
+}
+
+// WithConverterOptions configures the converter of the pages.
+// The link resolver and asset store are set by the Exporter.
+func WithConverterOptions(opts ...n_goldmark.Option) Option {
+	return func(e *Exporter) { e.opts = append(e.opts, opts...) }
+}
+
+// WithHTTPClient sets the client that downloads the assets.
+// By default, http.DefaultClient is used.
+func WithHTTPClient(cli *http.Client) Option {
+	return func(e *Exporter) { e.httpClient = cli }
+}
+
+// WithIncremental only exports what changed since the previous export into the same file system,
+// which is read from its manifest. See Exporter for details.
+func WithIncremental() Option {
+	return func(e *Exporter) { e.incremental = true }
+}
+
+// Exporter exports Notion pages with all their descendants into a file system.
+//
+// Every page, database and database entry is written to its own file,
+// e.g. "Page 96245c8f178444a482ad1941127c3ec3.html", and its descendants and
+// assets are written to the directory of the same name, e.g. "Page 96245c8f178444a482ad1941127c3ec3/".
+// Links between exported pages are relative, links to other pages point to Notion.
+// Archived pages are not exported.
+//
+// Exports can be incremental, using the manifest of the previous export as its state.
+// Since Notion updates when a page was last edited whenever its content changes,
+// the children of unchanged pages are not fetched again, and unchanged pages and
+// databases are only rendered again if the pages they link to were renamed or moved,
+// or, for database entries shown in tables, edited. The outputs of pages that were
+// renamed or moved along with their ancestors are moved, and the outputs of pages that
+// were archived or deleted are removed.
+type Exporter struct {
+	cli         notion.Getter
+	fs          afero.Fs
+	format      Format
+	opts        []n_goldmark.Option
+	httpClient  *http.Client
+	incremental bool
+}
+
+// NewExporter returns a new Exporter that fetches the pages with cli and writes them to fs.
+func NewExporter(cli notion.Getter, fs afero.Fs, opts ...Option) *Exporter {
+	e := &Exporter{
+		// the pages are fetched while walking and again while converting
+		cli: cache.NewGetter(cli, nil),
+		fs:  fs,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.format.Renderer == nil && e.format.NewRenderer == nil {
+		e.format = HTML()
+	}
+
+	return e
+}
+
+// Export exports the page with all its descendants and writes the manifest to ManifestFile.
+//
+// If only some assets could not be downloaded, the pages are exported anyway,
+// linking to Notion instead, and the manifest is returned together with an
+// n_goldmark.AssetErrors error.
+func (e *Exporter) Export(ctx context.Context, id notion.Id) (*Manifest, error) {
+	return e.run(ctx, docs.TypePage, id)
+}
+
+// ExportDatabase exports the database with all its entries and their descendants like Export.
+func (e *Exporter) ExportDatabase(ctx context.Context, id notion.Id) (*Manifest, error) {
+	return e.run(ctx, docs.TypeDatabase, id)
+}
+
+func (e *Exporter) run(ctx context.Context, tp docs.Type, id notion.Id) (*Manifest, error) {
+	prev := &Manifest{}
+	if e.incremental {
+		var err error
+		if prev, err = readManifest(e.fs); err != nil {
+			return nil, err
+		}
+	}
+
+	w := newWalker(ctx, e.cli, prev)
+	if err := docs.Walk(ctx, w, tp, id); err != nil {
+		return nil, err
+	}
+
+	if len(w.order) == 0 {
+		return nil, fmt.Errorf("%s %s cannot be exported", tp, id)
+	}
+
+	moved, err := e.move(w)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Root: w.order[0].id}
+
+	var assetErrs n_goldmark.AssetErrors
+
+	for _, o := range w.order {
+		if f, ok := e.unchanged(w, moved, o); ok {
+			m.Files = append(m.Files, f)
+			continue
+		}
+
+		f, err := e.export(ctx, w, o)
+
+		var errs n_goldmark.AssetErrors
+		switch {
+		case errors.As(err, &errs):
+			assetErrs = append(assetErrs, errs...)
+		case err != nil:
+			return nil, err
+		}
+
+		m.Files = append(m.Files, f)
+	}
+
+	if err := e.clean(w, moved, m); err != nil {
+		return nil, err
+	}
+
+	if err := m.write(e.fs); err != nil {
+		return nil, err
+	}
+
+	if len(assetErrs) > 0 {
+		return m, assetErrs
+	}
+
+	return m, nil
+}
+
+// export converts the page or database and writes it to its file.
+func (e *Exporter) export(ctx context.Context, w *walker, o *object) (File, error) {
+	f := File{
+		ID:             o.id,
+		Type:           o.tp,
+		Title:          o.title,
+		Path:           o.path + e.format.Ext,
+		LastEditedTime: o.edited,
+		Parent:         o.parent,
+	}
+
+	links := &linkRecorder{resolver: e.links(w, f.Path)}
+
+	// the assets and other files are stored relative to the file
+	dir := e.dirFS(path.Dir(f.Path))
+	assets := &assetStore{store: n_goldmark.NewFSAssetStore(dir, e.httpClient)}
+	files := &fileRecorder{Fs: dir, assets: assets}
+
+	c := n_goldmark.NewConverter(e.cli, append(e.opts,
+		n_goldmark.WithLinkResolver(links),
+		n_goldmark.WithAssetStore(assets))...)
+
+	var (
+		doc    *ast.Document
+		source []byte
+		err    error
+	)
+
+	switch o.tp {
+	case docs.TypePage:
+		doc, source, err = c.Page(ctx, notion.Id(o.id))
+	default:
+		doc, source, err = c.Database(ctx, notion.Id(o.id))
+	}
+
+	var errs n_goldmark.AssetErrors
+	if err != nil && !errors.As(err, &errs) {
+		return f, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := e.format.renderer(files).Render(buf, source, doc); err != nil {
+		return f, err
+	}
+
+	if err := e.fs.MkdirAll(path.Dir(f.Path), 0o755); err != nil {
+		return f, err
+	}
+
+	if err := afero.WriteFile(e.fs, f.Path, buf.Bytes(), 0o644); err != nil {
+		return f, err
+	}
+
+	f.Assets = assets.stored(path.Dir(f.Path))
+	f.Links = links.linked()
+
+	return f, err
+}
+
+// dirFS returns the file system of the directory.
+func (e *Exporter) dirFS(dir string) afero.Fs {
+	if dir == "." {
+		return e.fs
+	}
+
+	return afero.NewBasePathFs(e.fs, dir)
+}
+
+// links links to the files of exported pages relative to the file at from.
+// Other pages, e.g. archived ones, are linked to on Notion.
+func (e *Exporter) links(w *walker, from string) n_goldmark.LinkResolver {
+	return n_goldmark.LinkResolverFunc(func(l n_goldmark.PageLink) string {
+		o, ok := w.objects[key(notion.Id(l.ID))]
+		if !ok {
+			l.Outside, l.Dir = true, ""
+			return n_goldmark.NotionExportLinks.ResolveLink(l)
+		}
+
+		rel, err := filepath.Rel(path.Dir(from), o.path+e.format.Ext)
+		if err != nil {
+			return n_goldmark.NotionExportLinks.ResolveLink(l)
+		}
+
+		return filepath.ToSlash(rel)
+	})
+}
+
+// object is a page or database that is exported.
+type object struct {
+	id     notion.UUID
+	tp     docs.Type
+	title  string
+	edited time.Time
+	parent notion.UUID // of the closest page or database it is in
+
+	// path is the path of its file without the extension,
+	// which is also the directory of its descendants and assets
+	path string
+}
+
+// walker collects the pages and databases to export.
+// It remembers the parents of all blocks and database entries
+// to find out which page or database they are in.
+type walker struct {
+	notion.Getter
+
+	ctx  context.Context
+	prev *previous
+
+	objects  map[string]*object // by ID without dashes
+	order    []*object
+	parents  map[string]string    // by ID without dashes
+	children map[string][]*object // by ID of the parent without dashes
+}
+
+func newWalker(ctx context.Context, cli notion.Getter, prev *Manifest) *walker {
+	return &walker{
+		Getter:   cli,
+		ctx:      ctx,
+		prev:     newPrevious(prev),
+		objects:  map[string]*object{},
+		parents:  map[string]string{},
+		children: map[string][]*object{},
+	}
+}
+
+// GetNotionDatabase implements notion.Getter.
+// Databases that are only referenced by a child database block are skipped,
+// as are databases that cannot be found, e.g. because they are not shared with the integration.
+func (w *walker) GetNotionDatabase(ctx context.Context, id notion.Id) (*notion.Database, error) {
+	db, err := w.Getter.GetNotionDatabase(ctx, id)
+
+	switch {
+	case n_goldmark.IsNotFound(err):
+		return nil, docs.Skip
+	case err != nil:
+		return nil, err
+	}
+
+	if key(notion.Id(db.Id)) != key(id) {
+		return nil, docs.Skip
+	}
+
+	return db, nil
+}
+
+// GetAllBlocks implements notion.Getter.
+func (w *walker) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	blocks, err := w.Getter.GetAllBlocks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range blocks {
+		w.parents[key(notion.Id(b.Id))] = key(id)
+	}
+
+	return blocks, nil
+}
+
+// GetAllDatabaseEntries implements notion.Getter.
+func (w *walker) GetAllDatabaseEntries(ctx context.Context, id notion.Id) (notion.Pages, error) {
+	entries, err := w.Getter.GetAllDatabaseEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range entries {
+		w.parents[key(notion.Id(p.Id))] = key(id)
+	}
+
+	return entries, nil
+}
+
+// VisitPage implements docs.GetterVisitor.
+func (w *walker) VisitPage(p *notion.Page) error {
+	if p.Archived {
+		return docs.Skip
+	}
+
+	o := &object{id: p.Id, tp: docs.TypePage, title: p.Title(), edited: p.LastEditedTime}
+	if err := w.add(o); err != nil {
+		return err
+	}
+
+	if old, ok := w.prev.file(o.id); ok && old.LastEditedTime.Equal(o.edited) {
+		return w.walkPrevious(o)
+	}
+
+	return nil
+}
+
+// VisitBlock implements docs.GetterVisitor.
+func (w *walker) VisitBlock(notion.Block) error { return nil }
+
+// VisitDatabase implements docs.GetterVisitor.
+func (w *walker) VisitDatabase(db *notion.Database) error {
+	return w.add(&object{id: db.Id, tp: docs.TypeDatabase, title: db.Title.Content(), edited: db.LastEditedTime})
+}
+
+// add adds the object to the export, skipping objects that were already added.
+func (w *walker) add(o *object) error {
+	k := key(notion.Id(o.id))
+	if _, ok := w.objects[k]; ok {
+		return docs.Skip
+	}
+
+	o.path = n_goldmark.FileName(o.title, o.id)
+
+	if parent := w.parent(k); parent != nil {
+		o.parent = parent.id
+		o.path = path.Join(parent.path, o.path)
+		w.children[key(notion.Id(parent.id))] = append(w.children[key(notion.Id(parent.id))], o)
+	}
+
+	w.objects[k] = o
+	w.order = append(w.order, o)
+
+	return nil
+}
+
+// parent returns the closest page or database the object with the given key is in.
+// Its path is the directory of the object.
+func (w *walker) parent(k string) *object {
+	for k, ok := w.parents[k]; ok; k, ok = w.parents[k] {
+		if o, ok := w.objects[k]; ok {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// key returns the ID without dashes.
+func key(id notion.Id) string {
+	return strings.ReplaceAll(string(id), "-", "")
+}
@@ -0,0 +1,200 @@
+package export_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/export"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/samber/lo"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// converterOptions are needed because the fake client does not contain all the blocks of the example page.
+var converterOptions = export.WithConverterOptions(
+	n_goldmark.WithMaxBlocks(57),
+	n_goldmark.WithUnsupportedPolicy(n_goldmark.UnsupportedSkip),
+)
+
+// the files are not actually downloaded
+var httpClient = &http.Client{Transport: roundTripper(func(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+})}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return rt(req) }
+
+func files(t *testing.T, fsys afero.Fs) []string {
+	t.Helper()
+
+	paths := []string{}
+	assert.NoError(t, afero.Walk(fsys, "", func(p string, info fs.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			paths = append(paths, strings.TrimPrefix(p, "/"))
+		}
+
+		return err
+	}))
+
+	return paths
+}
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	fsys := afero.NewMemMapFs()
+
+	m, err := export.NewExporter(cli, fsys, converterOptions, export.WithHTTPClient(httpClient)).
+		Export(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	// the same files as Notion's export, except for the linked database,
+	// which the fake client cannot return, and the audio file after the last converted block
+	want := []string{export.ManifestFile}
+	assert.NoError(t, fs.WalkDir(fake.HTMLExport, "html", func(p string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir(),
+			strings.HasSuffix(p, ".ogg"),
+			strings.Contains(p, "d105edb4586a4dccaaa6ea944eb8d864"):
+			return nil
+		}
+
+		want = append(want, strings.TrimPrefix(p, "html/"))
+
+		return nil
+	}))
+
+	assert.ElementsMatch(t, want, files(t, fsys))
+
+	// the manifest lists all files
+	b, err := afero.ReadFile(fsys, export.ManifestFile)
+	assert.NoError(t, err)
+
+	written := &export.Manifest{}
+	assert.NoError(t, json.Unmarshal(b, written))
+	assert.Equal(t, m, written)
+
+	listed := []string{export.ManifestFile}
+	for _, f := range m.Files {
+		listed = append(listed, f.Path)
+		listed = append(listed, f.Assets...)
+	}
+
+	// assets of database entries are also listed with the pages that show the database
+	assert.ElementsMatch(t, want, lo.Uniq(listed))
+
+	if assert.NotEmpty(t, m.Files) {
+		assert.Equal(t, notion.UUID(fake.PageID), m.Root)
+		assert.Equal(t, m.Root, m.Files[0].ID)
+		assert.Equal(t, "Example Page", m.Files[0].Title)
+	}
+
+	// all relative links point to exported files,
+	// except for the values of properties, e.g. phone numbers
+	ref := regexp.MustCompile(`(?:href|src)="([^"]+)"( class="url-value")?`)
+
+	for _, f := range m.Files {
+		b, err := afero.ReadFile(fsys, f.Path)
+		assert.NoError(t, err)
+
+		for _, match := range ref.FindAllSubmatch(b, -1) {
+			dest, err := url.PathUnescape(string(match[1]))
+			assert.NoError(t, err)
+
+			if len(match[2]) > 0 || strings.Contains(dest, ":") || strings.HasPrefix(dest, "#") {
+				continue
+			}
+
+			ok, err := afero.Exists(fsys, path.Join(path.Dir(f.Path), dest))
+			assert.NoError(t, err)
+			assert.True(t, ok, "%s links to missing %s", f.Path, dest)
+		}
+	}
+}
+
+func TestExporter_Markdown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	fsys := afero.NewMemMapFs()
+
+	m, err := export.NewExporter(cli, fsys,
+		converterOptions, export.WithHTTPClient(httpClient), export.WithFormat(export.Markdown())).
+		Export(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	root := "Example Page 96245c8f178444a482ad1941127c3ec3"
+
+	b, err := afero.ReadFile(fsys, root+".md")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b),
+		"[My child page](Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20child%20page%202633808e7e364f4e972accd2d3c49004.md)")
+
+	for _, f := range m.Files {
+		assert.True(t, strings.HasSuffix(f.Path, ".md"), f.Path)
+	}
+
+	// the child database is also written as a CSV file, which is listed with the page
+	csv := root + "/My Child Database 7a3c647e4c1e4c27bf1dcfb0105e55ce.csv"
+
+	b, err = afero.ReadFile(fsys, csv)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "entry 1")
+
+	if assert.NotEmpty(t, m.Files) {
+		assert.Contains(t, m.Files[0].Assets, csv)
+	}
+}
+
+func TestExporter_Titles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	fsys := afero.NewMemMapFs()
+
+	// titles cannot create directories or leave the exported directory
+	g := &changingGetter{Getter: cli, renamed: map[notion.UUID]string{
+		rootID:      "../Q1/Q2 plan",
+		childPageID: `..\notes`,
+	}}
+
+	_, err = export.NewExporter(g, fsys, converterOptions, export.WithHTTPClient(httpClient)).
+		Export(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	root := "Q1 Q2 plan 96245c8f178444a482ad1941127c3ec3"
+
+	paths := files(t, fsys)
+	assert.Contains(t, paths, root+".html")
+	assert.Contains(t, paths, root+"/notes 2633808e7e364f4e972accd2d3c49004.html")
+
+	for _, p := range paths {
+		assert.True(t, p == export.ManifestFile || p == root+".html" || strings.HasPrefix(p, root+"/"), p)
+	}
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"github.com/faetools/notion-to-goldmark/renderer/html"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer"
+)
+
+// Format describes how the pages are rendered.
+type Format struct {
+	// Renderer renders the converted pages.
+	Renderer renderer.Renderer
+	// NewRenderer, if set, is used instead of Renderer to render each page.
+	// It gets the file system of the directory of the page's file,
+	// e.g. to write further files next to it.
+	NewRenderer func(fs afero.Fs) renderer.Renderer
+	// Ext is the extension of the files, e.g. ".html".
+	Ext string
+}
+
+// HTML renders the pages as HTML, like Notion's HTML export.
+func HTML(opts ...html.Option) Format {
+	md := goldmark.New(goldmark.WithExtensions(html.NewExtender(opts...)))
+
+	return Format{Renderer: md.Renderer(), Ext: ".html"}
+}
+
+// Markdown renders the pages as Markdown, like Notion's Markdown export.
+// Child databases shown as tables are written as CSV files next to the pages.
+func Markdown(opts ...markdown.Option) Format {
+	return Format{
+		Renderer: markdown.New(opts...),
+		NewRenderer: func(fs afero.Fs) renderer.Renderer {
+			return markdown.New(append(opts[:len(opts):len(opts)], markdown.WithFS(fs))...)
+		},
+		Ext: ".md",
+	}
+}
+
+// renderer returns the renderer of the page whose directory is fs.
+func (f Format) renderer(fs afero.Fs) renderer.Renderer {
+	if f.NewRenderer != nil {
+		return f.NewRenderer(fs)
+	}
+
+	return f.Renderer
+}
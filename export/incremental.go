@@ -0,0 +1,406 @@
+package export
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/docs"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+)
+
+// previous is the state of the previous export.
+type previous struct {
+	files    map[string]File   // by ID without dashes
+	children map[string][]File // by ID of the parent without dashes
+	order    []File
+}
+
+func newPrevious(m *Manifest) *previous {
+	p := &previous{
+		files:    map[string]File{},
+		children: map[string][]File{},
+		order:    m.Files,
+	}
+
+	for _, f := range m.Files {
+		p.files[key(notion.Id(f.ID))] = f
+
+		if f.Parent != "" {
+			k := key(notion.Id(f.Parent))
+			p.children[k] = append(p.children[k], f)
+		}
+	}
+
+	return p
+}
+
+func (p *previous) file(id notion.UUID) (File, bool) {
+	f, ok := p.files[key(notion.Id(id))]
+	return f, ok
+}
+
+// walkPrevious walks the children the unchanged page had in the previous export
+// instead of fetching its blocks again.
+func (w *walker) walkPrevious(o *object) error {
+	for _, c := range w.prev.children[key(notion.Id(o.id))] {
+		parent, err := w.currentParent(c)
+		switch {
+		case n_goldmark.IsNotFound(err):
+			// deleted since
+			continue
+		case err != nil:
+			return err
+		case parent != nil && parent.PageId != nil && key(notion.Id(*parent.PageId)) != key(notion.Id(o.id)):
+			// moved somewhere else since
+			continue
+		}
+
+		w.parents[key(notion.Id(c.ID))] = key(notion.Id(o.id))
+
+		if err := docs.Walk(w.ctx, w, c.Type, notion.Id(c.ID)); err != nil {
+			return err
+		}
+	}
+
+	return docs.Skip
+}
+
+// currentParent returns the current parent of the page or database of the previous export.
+func (w *walker) currentParent(f File) (*notion.Parent, error) {
+	if f.Type == docs.TypeDatabase {
+		db, err := w.Getter.GetNotionDatabase(w.ctx, notion.Id(f.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		return db.Parent, nil
+	}
+
+	p, err := w.GetNotionPage(w.ctx, notion.Id(f.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Parent, nil
+}
+
+// rename is a directory that was renamed.
+type rename struct{ from, to string }
+
+// renames are applied in order.
+type renames []rename
+
+// apply returns where the file at p of the previous export is now.
+func (rs renames) apply(p string) string {
+	for _, r := range rs {
+		if p == r.from || strings.HasPrefix(p, r.from+"/") {
+			p = r.to + p[len(r.from):]
+		}
+	}
+
+	return p
+}
+
+// fileDir returns the directory of the descendants and assets of the file,
+// which is its path without the extension.
+func fileDir(f File) string {
+	k := key(notion.Id(f.ID))
+	if i := strings.LastIndex(f.Path, k); i >= 0 {
+		return f.Path[:i+len(k)]
+	}
+
+	return strings.TrimSuffix(f.Path, path.Ext(f.Path))
+}
+
+// move moves the outputs of the previous export whose path changed,
+// e.g. because the page or one of its ancestors was renamed or moved.
+// The files are moved as is and are rendered again later if needed.
+func (e *Exporter) move(w *walker) (renames, error) {
+	var moved renames
+
+	for _, o := range w.order {
+		old, ok := w.prev.file(o.id)
+		if !ok {
+			continue
+		}
+
+		if err := e.rename(moved.apply(old.Path), o.path+e.format.Ext); err != nil {
+			return nil, err
+		}
+
+		if dir := moved.apply(fileDir(old)); dir != o.path {
+			if err := e.rename(dir, o.path); err != nil {
+				return nil, err
+			}
+
+			moved = append(moved, rename{from: dir, to: o.path})
+		}
+	}
+
+	return moved, nil
+}
+
+// rename renames the file or directory, if it exists.
+// The files of directories are moved one by one, since not every afero.Fs can rename directories.
+func (e *Exporter) rename(from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	info, err := e.fs.Stat(from)
+	switch {
+	case errors.Is(err, afero.ErrFileNotFound):
+		return nil
+	case err != nil:
+		return err
+	case !info.IsDir():
+		if err := e.fs.MkdirAll(path.Dir(to), 0o755); err != nil {
+			return err
+		}
+
+		return e.fs.Rename(from, to)
+	}
+
+	var files, dirs []string
+
+	if err := afero.Walk(e.fs, from, func(p string, info fs.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir():
+			dirs = append(dirs, p)
+		default:
+			files = append(files, p)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, p := range files {
+		if err := e.rename(p, to+strings.TrimPrefix(filepath.ToSlash(p), from)); err != nil {
+			return err
+		}
+	}
+
+	// remove the deepest directories first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := e.fs.Remove(dirs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unchanged returns the file of the object if it does not need to be rendered again.
+func (e *Exporter) unchanged(w *walker, moved renames, o *object) (File, bool) {
+	old, ok := w.prev.file(o.id)
+	if !ok || old.Type != o.tp || !old.LastEditedTime.Equal(o.edited) ||
+		old.Path[len(fileDir(old)):] != e.format.Ext {
+		return File{}, false
+	}
+
+	f := File{
+		ID:             o.id,
+		Type:           o.tp,
+		Title:          o.title,
+		Path:           o.path + e.format.Ext,
+		LastEditedTime: o.edited,
+		Parent:         o.parent,
+		Links:          old.Links,
+	}
+
+	if ok, err := afero.Exists(e.fs, f.Path); err != nil || !ok {
+		return File{}, false
+	}
+
+	// the table of a database shows its entries
+	if o.tp == docs.TypeDatabase && w.entriesChanged(o.id) {
+		return File{}, false
+	}
+
+	for _, id := range old.Links {
+		if w.linkChanged(old, f, id, e.format.Ext) {
+			return File{}, false
+		}
+	}
+
+	for _, a := range old.Assets {
+		f.Assets = append(f.Assets, moved.apply(a))
+	}
+
+	return f, true
+}
+
+// linkChanged reports whether the link from the file to the page or database changed.
+func (w *walker) linkChanged(old, f File, id notion.UUID, ext string) bool {
+	was, wasExported := w.prev.file(id)
+	o, isExported := w.objects[key(notion.Id(id))]
+
+	switch {
+	case wasExported != isExported:
+		return true
+	case !isExported:
+		// links to Notion
+		return false
+	case was.Title != o.title:
+		return true
+	}
+
+	before, _ := filepath.Rel(path.Dir(old.Path), was.Path)
+	after, _ := filepath.Rel(path.Dir(f.Path), o.path+ext)
+
+	if before != after {
+		return true
+	}
+
+	// database entries are shown in the tables of their databases,
+	// which are linked to by the entries
+	parent, ok := w.objects[key(notion.Id(o.parent))]
+	if !ok || parent.tp != docs.TypeDatabase {
+		return false
+	}
+
+	return !was.LastEditedTime.Equal(o.edited) || w.entriesChanged(parent.id)
+}
+
+// entriesChanged reports whether entries were added to or removed from the database.
+func (w *walker) entriesChanged(id notion.UUID) bool {
+	k := key(notion.Id(id))
+
+	before := w.prev.children[k]
+	after := w.children[k]
+
+	if len(before) != len(after) {
+		return true
+	}
+
+	ids := map[string]bool{}
+	for _, f := range before {
+		ids[key(notion.Id(f.ID))] = true
+	}
+
+	for _, o := range after {
+		if !ids[key(notion.Id(o.id))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clean removes the outputs of the previous export that are not part of the manifest,
+// e.g. of archived pages or assets that were removed, as well as directories that became empty.
+func (e *Exporter) clean(w *walker, moved renames, m *Manifest) error {
+	keep := map[string]bool{ManifestFile: true}
+
+	for _, f := range m.Files {
+		keep[f.Path] = true
+
+		for _, a := range f.Assets {
+			keep[a] = true
+		}
+	}
+
+	dirs := map[string]bool{}
+
+	for _, old := range w.prev.order {
+		dirs[moved.apply(fileDir(old))] = true
+
+		for _, p := range append([]string{old.Path}, old.Assets...) {
+			p = moved.apply(p)
+			if keep[p] {
+				continue
+			}
+
+			if err := e.fs.Remove(p); err != nil && !errors.Is(err, afero.ErrFileNotFound) {
+				return err
+			}
+
+			dirs[path.Dir(p)] = true
+		}
+	}
+
+	// remove the deepest directories first
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	for _, dir := range sorted {
+		if err := e.removeEmpty(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeEmpty removes the directory and its ancestors as long as they are empty.
+func (e *Exporter) removeEmpty(dir string) error {
+	for ; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		infos, err := afero.ReadDir(e.fs, dir)
+		switch {
+		case errors.Is(err, afero.ErrFileNotFound):
+			continue
+		case err != nil:
+			return err
+		case len(infos) > 0:
+			return nil
+		}
+
+		if err := e.fs.Remove(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linkRecorder remembers which pages and databases were linked to.
+// It is called concurrently while the blocks are converted.
+type linkRecorder struct {
+	resolver n_goldmark.LinkResolver
+
+	mu  sync.Mutex
+	ids map[notion.UUID]bool
+}
+
+// ResolveLink implements n_goldmark.LinkResolver.
+func (r *linkRecorder) ResolveLink(l n_goldmark.PageLink) string {
+	r.mu.Lock()
+	if r.ids == nil {
+		r.ids = map[notion.UUID]bool{}
+	}
+
+	r.ids[l.ID] = true
+	r.mu.Unlock()
+
+	return r.resolver.ResolveLink(l)
+}
+
+// linked returns the IDs of the pages and databases that were linked to.
+func (r *linkRecorder) linked() []notion.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []notion.UUID
+	for id := range r.ids {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
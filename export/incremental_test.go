@@ -0,0 +1,223 @@
+package export_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/export"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rootID      = notion.UUID(fake.PageID)
+	childPageID = "2633808e-7e36-4f4e-972a-ccd2d3c49004"
+	entry2ID    = "5f17fb6b-2318-4521-b83d-c0f5438abd0b"
+)
+
+// changingGetter changes the pages of another notion.Getter
+// and records which blocks were fetched.
+type changingGetter struct {
+	notion.Getter
+
+	mu       sync.Mutex
+	renamed  map[notion.UUID]string
+	archived map[notion.UUID]bool
+	fetched  []notion.Id
+}
+
+func (g *changingGetter) change(p *notion.Page) *notion.Page {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	title, ok := g.renamed[p.Id]
+	if !ok && !g.archived[p.Id] {
+		return p
+	}
+
+	changed := *p
+	changed.LastEditedTime = p.LastEditedTime.Add(time.Hour)
+	changed.Archived = g.archived[p.Id]
+
+	if ok {
+		changed.Properties = notion.PropertyValueMap{}
+		for name, prop := range p.Properties {
+			if prop.Type == notion.PropertyTypeTitle {
+				rts := notion.NewRichTexts(title)
+				prop.Title = &rts
+			}
+
+			changed.Properties[name] = prop
+		}
+	}
+
+	return &changed
+}
+
+func (g *changingGetter) GetNotionPage(ctx context.Context, id notion.Id) (*notion.Page, error) {
+	p, err := g.Getter.GetNotionPage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.change(p), nil
+}
+
+func (g *changingGetter) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	g.mu.Lock()
+	g.fetched = append(g.fetched, id)
+	g.mu.Unlock()
+
+	return g.Getter.GetAllBlocks(ctx, id)
+}
+
+func (g *changingGetter) GetAllDatabaseEntries(ctx context.Context, id notion.Id) (notion.Pages, error) {
+	entries, err := g.Getter.GetAllDatabaseEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := notion.Pages{}
+
+	for _, p := range entries {
+		if p := g.change(&p); !p.Archived {
+			changed = append(changed, *p)
+		}
+	}
+
+	return changed, nil
+}
+
+// fetchedPages returns the IDs of the pages whose blocks were fetched.
+func (g *changingGetter) fetchedPages(m *export.Manifest) []notion.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pages := []notion.UUID{}
+
+	for _, f := range m.Files {
+		for _, id := range g.fetched {
+			if id == notion.Id(f.ID) {
+				pages = append(pages, f.ID)
+				break
+			}
+		}
+	}
+
+	return pages
+}
+
+func readFiles(t *testing.T, fsys afero.Fs) map[string]string {
+	t.Helper()
+
+	contents := map[string]string{}
+
+	for _, p := range files(t, fsys) {
+		b, err := afero.ReadFile(fsys, p)
+		assert.NoError(t, err)
+
+		contents[p] = string(b)
+	}
+
+	return contents
+}
+
+func TestExporter_Incremental(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	fsys := afero.NewMemMapFs()
+
+	exportWith := func(t *testing.T, g *changingGetter) *export.Manifest {
+		t.Helper()
+
+		m, err := export.NewExporter(g, fsys,
+			converterOptions, export.WithHTTPClient(httpClient), export.WithIncremental()).
+			Export(ctx, fake.PageID)
+		assert.NoError(t, err)
+
+		return m
+	}
+
+	root := "Example Page 96245c8f178444a482ad1941127c3ec3"
+
+	first := exportWith(t, &changingGetter{Getter: cli})
+	before := readFiles(t, fsys)
+
+	// nothing changed
+	g := &changingGetter{Getter: cli}
+	assert.Equal(t, first, exportWith(t, g))
+	assert.Empty(t, g.fetchedPages(first))
+	assert.Equal(t, before, readFiles(t, fsys))
+
+	// a child page was renamed
+	g = &changingGetter{Getter: cli, renamed: map[notion.UUID]string{childPageID: "Renamed page"}}
+	m := exportWith(t, g)
+
+	// the page and its parent that links to it are rendered again
+	assert.Equal(t, []notion.UUID{rootID, childPageID}, g.fetchedPages(m))
+
+	after := readFiles(t, fsys)
+	assert.NotContains(t, after, root+"/My child page 2633808e7e364f4e972accd2d3c49004.html")
+	assert.Contains(t, after, root+"/Renamed page 2633808e7e364f4e972accd2d3c49004.html")
+	assert.Contains(t, after[root+".html"], "Renamed%20page%202633808e7e364f4e972accd2d3c49004.html")
+
+	// the root page was renamed, moving all pages
+	g = &changingGetter{Getter: cli, renamed: map[notion.UUID]string{
+		childPageID: "Renamed page",
+		rootID:      "Renamed root",
+	}}
+	m = exportWith(t, g)
+
+	// only the root page is rendered again, the descendants are moved as is
+	assert.Equal(t, []notion.UUID{rootID}, g.fetchedPages(m))
+
+	newRoot := "Renamed root 96245c8f178444a482ad1941127c3ec3"
+	moved := readFiles(t, fsys)
+
+	for p, content := range after {
+		if p == root+".html" || p == export.ManifestFile {
+			continue
+		}
+
+		assert.Equal(t, content, moved[newRoot+strings.TrimPrefix(p, root)], p)
+	}
+
+	assert.Len(t, moved, len(after))
+
+	// an entry was archived
+	g = &changingGetter{
+		Getter: cli,
+		renamed: map[notion.UUID]string{
+			childPageID: "Renamed page",
+			rootID:      "Renamed root",
+		},
+		archived: map[notion.UUID]bool{entry2ID: true},
+	}
+	m = exportWith(t, g)
+
+	// the root page shows the table of the database
+	assert.Equal(t, []notion.UUID{rootID}, g.fetchedPages(m))
+
+	archived := readFiles(t, fsys)
+	assert.Len(t, archived, len(moved)-1)
+	assert.NotContains(t, archived,
+		newRoot+"/My Child Database 7a3c647e4c1e4c27bf1dcfb0105e55ce/entry 2 5f17fb6b23184521b83dc0f5438abd0b.html")
+	assert.Contains(t, before[root+".html"], "entry%202%205f17fb6b23184521b83dc0f5438abd0b.html")
+	assert.NotContains(t, archived[newRoot+".html"], "entry%202%205f17fb6b23184521b83dc0f5438abd0b.html")
+	assert.NotContains(t, archived[newRoot+"/My Child Database 7a3c647e4c1e4c27bf1dcfb0105e55ce.html"],
+		"entry%202%205f17fb6b23184521b83dc0f5438abd0b.html")
+
+	for _, f := range m.Files {
+		assert.NotEqual(t, notion.UUID(entry2ID), f.ID)
+	}
+}
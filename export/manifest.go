@@ -0,0 +1,67 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/docs"
+	"github.com/spf13/afero"
+)
+
+// ManifestFile is the file the manifest is written to.
+const ManifestFile = "manifest.json"
+
+// Manifest lists the files written by an export.
+type Manifest struct {
+	// Root is the ID of the exported page or database.
+	Root  notion.UUID `json:"root"`
+	Files []File      `json:"files"`
+}
+
+// File describes the file of an exported page or database.
+type File struct {
+	ID             notion.UUID `json:"id"`
+	Type           docs.Type   `json:"type"`
+	Title          string      `json:"title"`
+	LastEditedTime time.Time   `json:"last_edited_time"`
+	// Path is the path of the file, relative to the root of the export.
+	Path string `json:"path"`
+	// Parent is the ID of the closest exported page or database it is in, if any.
+	Parent notion.UUID `json:"parent,omitempty"`
+	// Assets are the paths of the downloaded assets and other files written next to it,
+	// e.g. the CSV files of child databases, relative to the root of the export.
+	Assets []string `json:"assets,omitempty"`
+	// Links are the IDs of the pages and databases it links to.
+	Links []notion.UUID `json:"links,omitempty"`
+}
+
+// readManifest reads the manifest of the previous export, if there is one.
+func readManifest(fsys afero.Fs) (*Manifest, error) {
+	b, err := afero.ReadFile(fsys, ManifestFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return &Manifest{}, nil
+	case err != nil:
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ManifestFile, err)
+	}
+
+	return m, nil
+}
+
+func (m *Manifest) write(fs afero.Fs) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, ManifestFile, append(b, '\n'), 0o644)
+}
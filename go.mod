@@ -3,18 +3,25 @@ module github.com/faetools/notion-to-goldmark
 go 1.18
 
 require (
+	github.com/alecthomas/chroma v0.10.0
+	github.com/faetools/client v0.0.0-20220318211513-a9b944e5b437
 	github.com/faetools/go-notion v0.0.28
+	github.com/pelletier/go-toml v1.9.4
 	github.com/samber/lo v1.25.0
+	github.com/spf13/afero v1.8.0
 	github.com/stretchr/testify v1.7.2
+	github.com/tdewolff/parse/v2 v2.5.27
 	github.com/yuin/goldmark v1.4.13
+	github.com/yuin/goldmark-meta v1.1.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/containerd/typeurl v1.0.2 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/deepmap/oapi-codegen v1.11.0 // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
 	github.com/faetools/cgtools v0.0.4 // indirect
-	github.com/faetools/client v0.0.0-20220318211513-a9b944e5b437 // indirect
 	github.com/faetools/format v0.0.0-20220414215708-3bef0e0cc085 // indirect
 	github.com/faetools/kit v0.0.7 // indirect
 	github.com/fatih/color v1.13.0 // indirect
@@ -31,19 +38,15 @@ require (
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/mitchellh/mapstructure v1.4.3 // indirect
 	github.com/moby/buildkit v0.9.3 // indirect
-	github.com/pelletier/go-toml v1.9.4 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/spf13/afero v1.8.0 // indirect
 	github.com/spf13/cast v1.4.1 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.10.1 // indirect
 	github.com/subosito/gotenv v1.2.0 // indirect
 	github.com/tdewolff/minify/v2 v2.10.0 // indirect
-	github.com/tdewolff/parse/v2 v2.5.27 // indirect
 	github.com/tidwall/pretty v1.2.0 // indirect
-	github.com/yuin/goldmark-meta v1.1.0 // indirect
 	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
 	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
@@ -53,7 +56,6 @@ require (
 	golang.org/x/xerrors v0.0.0-20220609144429-65e65417b02f // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
 	gopkg.in/ini.v1 v1.66.2 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	mvdan.cc/gofumpt v0.3.1 // indirect
 )
@@ -0,0 +1,245 @@
+package goldmark
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// An AssetStore stores files hosted by Notion.
+// Notion only hands out signed URLs that expire after an hour,
+// so they need to be stored somewhere else to keep working.
+// It is called concurrently while the blocks are converted.
+type AssetStore interface {
+	// Store stores the file at the signed URL in the directory dir.
+	// It returns the path the file is stored at, which is used instead of the URL.
+	Store(ctx context.Context, dir, rawURL string) (string, error)
+}
+
+// AssetError is reported if an asset could not be stored.
+type AssetError struct {
+	URL string
+	Err error
+}
+
+func (e *AssetError) Error() string {
+	return fmt.Sprintf("storing asset %s: %v", e.URL, e.Err)
+}
+
+func (e *AssetError) Unwrap() error { return e.Err }
+
+// AssetErrors is returned alongside the nodes if some assets could not be stored.
+// The links to these assets keep pointing to Notion.
+type AssetErrors []*AssetError
+
+func (es AssetErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// FSAssetStore is an AssetStore that downloads the assets into a file system.
+// Like Notion, it appends a number to the file name if a different file
+// with the same name has already been stored in the same directory.
+// Since blocks are converted concurrently, which of these files gets
+// the number depends on the order the requests finish in.
+type FSAssetStore struct {
+	fs  afero.Fs
+	cli *http.Client
+
+	mu     sync.Mutex
+	assets map[string]*asset // by source
+	paths  map[string]bool   // paths in use
+}
+
+type asset struct {
+	path string
+	done chan struct{}
+	err  error
+
+	waiting  int
+	canceled bool
+	cancel   context.CancelFunc
+}
+
+// NewFSAssetStore returns a new asset store that downloads the assets into fs.
+// If cli is nil, http.DefaultClient is used.
+func NewFSAssetStore(fs afero.Fs, cli *http.Client) *FSAssetStore {
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	return &FSAssetStore{
+		fs:     fs,
+		cli:    cli,
+		assets: map[string]*asset{},
+		paths:  map[string]bool{},
+	}
+}
+
+// Store implements AssetStore.
+// Each asset is downloaded once for all callers and only canceled if all of them are.
+// Assets that could not be downloaded are tried again the next time they are stored.
+func (s *FSAssetStore) Store(ctx context.Context, dir, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	// the signature changes every time the file is requested
+	src := *u
+	src.RawQuery = ""
+	key := dir + "\x00" + src.String()
+
+	s.mu.Lock()
+
+	// a canceled download still holds the path until it stops
+	for a, ok := s.assets[key]; ok && a.canceled; a, ok = s.assets[key] {
+		s.mu.Unlock()
+
+		select {
+		case <-a.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		// it was stored anyway
+		if a.err == nil {
+			return a.path, nil
+		}
+
+		s.mu.Lock()
+	}
+
+	a, ok := s.assets[key]
+	if !ok {
+		dlCtx, cancel := context.WithCancel(detached{ctx})
+
+		a = &asset{path: s.freePath(dir, assetName(u.Path)), done: make(chan struct{}), cancel: cancel}
+		s.assets[key] = a
+		s.paths[a.path] = true
+
+		go func() {
+			defer cancel()
+
+			a.err = s.download(dlCtx, a.path, rawURL)
+			if a.err != nil {
+				s.release(key, a)
+			}
+
+			close(a.done)
+		}()
+	}
+
+	a.waiting++
+	s.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return a.path, a.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		a.waiting--
+
+		if a.waiting == 0 {
+			a.canceled = true
+			a.cancel()
+		}
+
+		s.mu.Unlock()
+
+		return "", ctx.Err()
+	}
+}
+
+// release forgets the asset and frees its path, so it can be stored again.
+func (s *FSAssetStore) release(key string, a *asset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.assets[key] == a {
+		delete(s.assets, key)
+	}
+
+	delete(s.paths, a.path)
+}
+
+// assetName returns the name of the file at the URL path.
+// Names that would point to another directory are replaced.
+func assetName(p string) string {
+	name := pathSeparators.Replace(path.Base(p))
+	if strings.Trim(name, ". ") == "" {
+		return "Untitled"
+	}
+
+	return name
+}
+
+// freePath returns a path for the file name in dir that is not in use yet.
+func (s *FSAssetStore) freePath(dir, name string) string {
+	p := filepath.Join(dir, name)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; s.paths[p]; i++ {
+		p = filepath.Join(dir, base+" "+strconv.Itoa(i)+ext)
+	}
+
+	return p
+}
+
+func (s *FSAssetStore) download(ctx context.Context, p, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := s.fs.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	f, err := s.fs.Create(p)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = s.fs.Remove(p)
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// detached is a context with the values of another context that is never canceled.
+type detached struct{ ctx context.Context }
+
+func (detached) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detached) Done() <-chan struct{}               { return nil }
+func (detached) Err() error                          { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.ctx.Value(key) }
@@ -0,0 +1,223 @@
+package goldmark_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFSAssetStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a/cat.png":
+			_, _ = w.Write([]byte("first cat"))
+		case "/b/cat.png":
+			_, _ = w.Write([]byte("second cat"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fs := afero.NewMemMapFs()
+	s := NewFSAssetStore(fs, srv.Client())
+
+	p, err := s.Store(ctx, "page", srv.URL+"/a/cat.png?signature=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "page/cat.png", p)
+
+	// a new signature for the same file
+	p, err = s.Store(ctx, "page", srv.URL+"/a/cat.png?signature=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "page/cat.png", p)
+
+	// a different file with the same name
+	p, err = s.Store(ctx, "page", srv.URL+"/b/cat.png?signature=3")
+	assert.NoError(t, err)
+	assert.Equal(t, "page/cat 1.png", p)
+
+	// the same name in a different directory
+	p, err = s.Store(ctx, "other", srv.URL+"/b/cat.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "other/cat.png", p)
+
+	b, err := afero.ReadFile(fs, "page/cat.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "first cat", string(b))
+
+	b, err = afero.ReadFile(fs, "page/cat 1.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "second cat", string(b))
+
+	_, err = s.Store(ctx, "page", srv.URL+"/missing.png")
+	assert.EqualError(t, err, "unexpected status 404 Not Found")
+}
+
+func TestFSAssetStore_Names(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("file"))
+	}))
+	defer srv.Close()
+
+	fs := afero.NewMemMapFs()
+	s := NewFSAssetStore(fs, srv.Client())
+
+	// names cannot leave the directory
+	for _, tt := range []struct{ path, want string }{
+		{"/a/%2E%2E", "page/Untitled"},
+		{"/", "page/Untitled 1"},
+		{"/a/..%2F..%2Fevil.png", "page/evil.png"},
+		{"/a/..%5C..%5Cevil.png", "page/.. .. evil.png"},
+	} {
+		p, err := s.Store(ctx, "page", srv.URL+tt.path)
+		assert.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, p, tt.path)
+	}
+
+	names, err := afero.ReadDir(fs, "page")
+	assert.NoError(t, err)
+	assert.Len(t, names, 4)
+}
+
+func TestFSAssetStore_Retry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	failed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !failed {
+			failed = true
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("cat"))
+	}))
+	defer srv.Close()
+
+	s := NewFSAssetStore(afero.NewMemMapFs(), srv.Client())
+
+	_, err := s.Store(ctx, "page", srv.URL+"/cat.png")
+	assert.EqualError(t, err, "unexpected status 503 Service Unavailable")
+
+	// the failed download neither sticks nor keeps the name
+	p, err := s.Store(ctx, "page", srv.URL+"/cat.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "page/cat.png", p)
+}
+
+func TestFSAssetStore_Canceled(t *testing.T) {
+	t.Parallel()
+
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte("cat"))
+	}))
+	defer srv.Close()
+
+	s := NewFSAssetStore(afero.NewMemMapFs(), srv.Client())
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+
+	go func() {
+		_, err := s.Store(first, "page", srv.URL+"/cat.png")
+		firstErr <- err
+	}()
+
+	<-started
+
+	type result struct {
+		path string
+		err  error
+	}
+
+	second := make(chan result)
+
+	go func() {
+		p, err := s.Store(context.Background(), "page", srv.URL+"/cat.png")
+		second <- result{p, err}
+	}()
+
+	// canceling the first caller does not fail the second one
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+
+	res := <-second
+	assert.NoError(t, res.err)
+	assert.Equal(t, "page/cat.png", res.path)
+}
+
+func TestAssetErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer srv.Close()
+
+	image := func(id notion.UUID, name string) notion.Block {
+		return notion.Block{Id: id, Type: notion.BlockTypeImage, Image: &notion.FileWithCaption{
+			Type: notion.FileWithCaptionTypeFile,
+			File: &notion.NotionFile{Url: srv.URL + "/" + name},
+		}}
+	}
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: "96245c8f-1784-44a4-82ad-1941127c3ec3"}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{image("found", "found.png"), image("missing", "missing.png")}, nil
+		},
+	}
+
+	fs := afero.NewMemMapFs()
+
+	doc, source, err := NewConverter(cli, WithAssetStore(NewFSAssetStore(fs, srv.Client()))).Page(ctx, "page")
+
+	var assetErrs AssetErrors
+	if assert.True(t, errors.As(err, &assetErrs)) && assert.Len(t, assetErrs, 1) {
+		assert.Equal(t, srv.URL+"/missing.png", assetErrs[0].URL)
+	}
+
+	ok, err := afero.Exists(fs, "Untitled 96245c8f178444a482ad1941127c3ec3/found.png")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
+	assert.Equal(t, `<figure id="found" class="image"><a href="Untitled%2096245c8f178444a482ad1941127c3ec3/found.png">`+
+		`<img src="Untitled%2096245c8f178444a482ad1941127c3ec3/found.png"/></a></figure>`+
+		`<figure id="missing" class="image"><a href="`+srv.URL+`/missing.png">`+
+		`<img src="`+srv.URL+`/missing.png"/></a></figure>`, w.String())
+}
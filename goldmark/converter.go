@@ -0,0 +1,244 @@
+package goldmark
+
+import (
+	"context"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/yuin/goldmark/ast"
+)
+
+// A Converter converts Notion pages, blocks and databases into goldmark nodes.
+type Converter struct {
+	cli notion.Getter
+
+	maxBlocks   int
+	maxDepth    int
+	concurrency int
+	links       LinkResolver
+	assets      AssetStore
+	metadata    MetadataProvider
+	unsupported UnsupportedPolicy
+	textSource  bool
+	pageHeader  bool
+	frontMatter FrontMatterMapper
+	views       map[string]DatabaseView // by ID without dashes
+}
+
+// Option configures a Converter.
+type Option func(*Converter)
+
+// WithMaxBlocks limits the number of top-level blocks that are converted.
+// A negative number means no limit, which is the default.
+func WithMaxBlocks(max int) Option {
+	return func(c *Converter) { c.maxBlocks = max }
+}
+
+// WithMaxDepth limits how deeply nested blocks are converted,
+// e.g. with a depth of 0 the children of blocks are left out.
+// A negative number means no limit, which is the default.
+func WithMaxDepth(depth int) Option {
+	return func(c *Converter) { c.maxDepth = depth }
+}
+
+// WithConcurrency limits the number of concurrent requests to Notion.
+// The children of sibling blocks are fetched concurrently by as many workers. The default is 3.
+func WithConcurrency(n int) Option {
+	return func(c *Converter) { c.concurrency = n }
+}
+
+// WithLinkResolver sets how links to Notion pages are resolved.
+// By default, the links follow the layout of Notion's HTML export.
+func WithLinkResolver(r LinkResolver) Option {
+	return func(c *Converter) { c.links = r }
+}
+
+// WithAssetStore stores the files hosted by Notion in the asset store
+// and links to the stored files instead.
+func WithAssetStore(s AssetStore) Option {
+	return func(c *Converter) { c.assets = s }
+}
+
+// WithMetadataProvider fills in the title, description, favicon and image
+// of bookmarks with the metadata of the bookmarked pages.
+// If the metadata of a page cannot be provided, the bookmark only shows its URL,
+// which is also the default without a metadata provider.
+func WithMetadataProvider(m MetadataProvider) Option {
+	return func(c *Converter) { c.metadata = m }
+}
+
+// WithUnsupportedPolicy sets what happens with blocks and rich texts that cannot be converted.
+// By default, an error is returned.
+func WithUnsupportedPolicy(p UnsupportedPolicy) Option {
+	return func(c *Converter) { c.unsupported = p }
+}
+
+// WithSource backs all text with the returned source instead of ast.String nodes.
+// Goldmark's own renderers and extensions, e.g. to link headings or create footnotes,
+// read the text of ast.Text nodes from the source, so they work on the converted pages.
+// The text of code blocks is always backed by the source.
+func WithSource() Option {
+	return func(c *Converter) { c.textSource = true }
+}
+
+// WithPageHeader adds the cover, icon and title of a page as a n_ast.PageHeader
+// at the beginning of its document, like in Notion's export.
+func WithPageHeader() Option {
+	return func(c *Converter) { c.pageHeader = true }
+}
+
+// WithFrontMatter adds the fields of the front matter of a page as the metadata of its document,
+// like goldmark-meta does with meta.WithStoresInDocument.
+// The Markdown renderer writes it as front matter at the top of the file.
+func WithFrontMatter(m FrontMatterMapper) Option {
+	return func(c *Converter) { c.frontMatter = m }
+}
+
+// WithDatabaseView shows the database with the view wherever it is converted,
+// instead of showing all its entries and properties.
+func WithDatabaseView(id notion.Id, v DatabaseView) Option {
+	return func(c *Converter) {
+		if c.views == nil {
+			c.views = map[string]DatabaseView{}
+		}
+
+		c.views[dashless(notion.UUID(id))] = v
+	}
+}
+
+// NewConverter returns a new Converter that fetches the content with cli.
+func NewConverter(cli notion.Getter, opts ...Option) *Converter {
+	c := &Converter{
+		cli:         cli,
+		maxBlocks:   -1,
+		maxDepth:    -1,
+		concurrency: defaultConcurrency,
+		links:       NotionExportLinks,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.concurrency < 1 {
+		c.concurrency = 1
+	}
+
+	c.cli = newLimitGetter(cli, c.concurrency)
+
+	return c
+}
+
+// Page returns the content of a Notion page as a goldmark document.
+// The metadata of the page is available with GetPageMeta.
+// Links and assets are relative to the directory of the page.
+// The text of the document is backed by the returned source,
+// which needs to be passed on to the renderer.
+//
+// If only some assets could not be stored, the document is returned
+// together with an AssetErrors error.
+func (c *Converter) Page(ctx context.Context, id notion.Id) (*ast.Document, []byte, error) {
+	p, err := c.cli.GetNotionPage(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pc := &pageCollector{
+		Converter: c,
+		root:      getDir(p.Title(), p.Id),
+		ctx:       ctx,
+		cancel:    cancel,
+		pageID:    p.Id,
+		locations: map[notion.UUID]*location{
+			p.Id: {title: p.Title(), icon: p.Icon, parent: p.Parent, inside: true},
+		},
+	}
+
+	nodes, err := pc.getBlocks(id, c.maxBlocks, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := pc.addTablesOfContents(nodes); err != nil {
+		return nil, nil, err
+	}
+
+	if c.pageHeader {
+		header := pc.toNodePageHeader(p)
+		if err := pc.resolveMentions(header); err != nil {
+			return nil, nil, err
+		}
+
+		nodes = append([]ast.Node{header}, nodes...)
+	}
+
+	doc, source, err := pc.result(nodes)
+	meta := newPageMeta(p)
+	doc.SetAttributeString(attrPageMeta, meta)
+
+	if c.frontMatter != nil {
+		for k, v := range c.frontMatter.FrontMatter(meta) {
+			if v != nil {
+				doc.AddMeta(k, v)
+			}
+		}
+	}
+
+	return doc, source, err
+}
+
+// Blocks returns the children of a Notion block as a goldmark document.
+// Links and assets are relative to the current directory.
+// Like with Page, the text is backed by the returned source.
+//
+// If only some assets could not be stored, the document is returned
+// together with an AssetErrors error.
+func (c *Converter) Blocks(ctx context.Context, id notion.Id) (*ast.Document, []byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pc := &pageCollector{Converter: c, ctx: ctx, cancel: cancel, locations: map[notion.UUID]*location{}}
+
+	nodes, err := pc.getBlocks(id, c.maxBlocks, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := pc.addTablesOfContents(nodes); err != nil {
+		return nil, nil, err
+	}
+
+	return pc.result(nodes)
+}
+
+// Database returns a goldmark document with the entries of a Notion database as a table,
+// or in the layout of its view if it was given one with WithDatabaseView.
+// Links and assets are relative to the current directory.
+// Like with Page, the text is backed by the returned source.
+//
+// If only some assets could not be stored, the document is returned
+// together with an AssetErrors error.
+func (c *Converter) Database(ctx context.Context, id notion.Id) (*ast.Document, []byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pc := &pageCollector{Converter: c, ctx: ctx, cancel: cancel, locations: map[notion.UUID]*location{}}
+
+	db, err := pc.getDatabase(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := pc.resolveMentions(db); err != nil {
+		return nil, nil, err
+	}
+
+	n, err := pc.checkUnsupported(notion.UUID(id), db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pc.result([]ast.Node{n})
+}
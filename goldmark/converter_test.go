@@ -0,0 +1,310 @@
+package goldmark_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+)
+
+func TestConverter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	paragraph := func(id notion.UUID, txt string, hasChildren bool) notion.Block {
+		b := paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts(txt)})
+		b.Id = id
+		b.HasChildren = hasChildren
+
+		return b
+	}
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			switch id {
+			case "page":
+				return notion.Blocks{paragraph("p1", "One", true), paragraph("p2", "Two", false)}, nil
+			case "p1":
+				return notion.Blocks{paragraph("p3", "Three", true)}, nil
+			case "p3":
+				return notion.Blocks{paragraph("p4", "Four", false)}, nil
+			default:
+				return nil, fmt.Errorf("unknown block %q", id)
+			}
+		},
+	}
+
+	render := func(t *testing.T, doc *ast.Document, source []byte) string {
+		t.Helper()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+
+		return w.String()
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"all", nil, "One\n\nThree\n\nFour\n\nTwo\n"},
+		{"max blocks", []Option{WithMaxBlocks(1)}, "One\n\nThree\n\nFour\n"},
+		{"max depth", []Option{WithMaxDepth(1)}, "One\n\nThree\n\nTwo\n"},
+		{"no children", []Option{WithMaxDepth(0)}, "One\n\nTwo\n"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, source, err := NewConverter(cli, tt.opts...).Page(ctx, "page")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, render(t, doc, source))
+		})
+	}
+
+	doc, source, err := NewConverter(cli).Blocks(ctx, "p3")
+	assert.NoError(t, err)
+	assert.Equal(t, "Four\n", render(t, doc, source))
+}
+
+func TestConverter_WithSource(t *testing.T) {
+	t.Parallel()
+
+	render := func(t *testing.T, rd renderer.Renderer, opts ...Option) string {
+		t.Helper()
+
+		doc, source := getExamplePage(t, opts...)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, rd.Render(w, source, doc))
+
+		return w.String()
+	}
+
+	// the output does not change if the text is backed by the source
+	assert.Equal(t, render(t, r), render(t, r, WithSource()))
+	assert.Equal(t, render(t, markdown.New()), render(t, markdown.New(), WithSource()))
+
+	doc, _ := getExamplePage(t, WithSource())
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, ok := n.(*ast.String); ok {
+			t.Errorf("%s is not backed by the source", n.Parent().Kind())
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	// goldmark's own renderer can read the text
+	g := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			b := paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts("One & two")})
+			b.Id = "p1"
+
+			return notion.Blocks{b}, nil
+		},
+	}
+
+	doc, source, err := NewConverter(g, WithSource()).Page(context.Background(), "page")
+	assert.NoError(t, err)
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, goldmark.New().Renderer().Render(w, source, doc))
+	assert.Equal(t, `<p id="p1" class="">One &amp; two</p>`+"\n", w.String())
+}
+
+func TestConverter_Database(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	c := NewConverter(cli, WithLinkResolver(LinkResolverFunc(func(l PageLink) string {
+		return "/pages/" + string(l.ID)
+	})))
+
+	doc, _, err := c.Database(ctx, "7a3c647e-4c1e-4c27-bf1d-cfb0105e55ce")
+	assert.NoError(t, err)
+
+	table, ok := doc.FirstChild().(*extast.Table)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	// the title of the first entry links to the entry
+	row := table.FirstChild().NextSibling()
+	link, ok := row.FirstChild().FirstChild().(*ast.Link)
+	if assert.True(t, ok, "title cell should contain a link") {
+		assert.Regexp(t, `^/pages/[0-9a-f-]{36}$`, string(link.Destination))
+	}
+}
+
+func TestConverter_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	paragraph := func(id notion.UUID, hasChildren bool) notion.Block {
+		b := paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts(string(id))})
+		b.Id = id
+		b.HasChildren = hasChildren
+
+		return b
+	}
+
+	const limit = 2
+
+	var (
+		mu               sync.Mutex
+		running, maxSeen int
+	)
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			mu.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+
+			// give the other requests time to start
+			time.Sleep(10 * time.Millisecond)
+
+			switch id {
+			case "page":
+				return notion.Blocks{
+					paragraph("a", true), paragraph("b", true),
+					paragraph("c", true), paragraph("d", true),
+				}, nil
+			case "broken1", "broken2":
+				return nil, fmt.Errorf("%s is broken", id)
+			case "c":
+				return notion.Blocks{paragraph("broken1", true), paragraph("c1", false)}, nil
+			case "d":
+				return notion.Blocks{paragraph("broken2", true)}, nil
+			default:
+				return notion.Blocks{paragraph(notion.UUID(id)+"1", false)}, nil
+			}
+		},
+	}
+
+	t.Run("order", func(t *testing.T) {
+		doc, source, err := NewConverter(cli, WithConcurrency(limit), WithMaxBlocks(2)).Page(ctx, "page")
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, maxSeen, limit)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+		assert.Equal(t, "a\n\na1\n\nb\n\nb1\n", w.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, _, err := NewConverter(cli, WithConcurrency(limit)).Page(ctx, "page")
+
+		var fetchErrs FetchErrors
+		if assert.True(t, errors.As(err, &fetchErrs)) && assert.Len(t, fetchErrs, 2) {
+			assert.EqualValues(t, "broken1", fetchErrs[0].ID)
+			assert.EqualValues(t, "broken2", fetchErrs[1].ID)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, _, err := NewConverter(cli).Blocks(ctx, "page")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// waitingGetter waits for the context to be canceled when fetching the children of a block.
+type waitingGetter struct {
+	*testGetter
+	started, canceled chan struct{}
+}
+
+// GetAllBlocks implements notion.Getter.
+func (g *waitingGetter) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	if id != "waiting" {
+		return g.testGetter.GetAllBlocks(ctx, id)
+	}
+
+	close(g.started)
+
+	select {
+	case <-ctx.Done():
+		close(g.canceled)
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("not canceled")
+	}
+}
+
+func TestConverter_Failure(t *testing.T) {
+	t.Parallel()
+
+	waiting := paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts("waiting")})
+	waiting.Id, waiting.HasChildren = "waiting", true
+
+	started := make(chan struct{})
+
+	cli := &waitingGetter{
+		testGetter: &testGetter{
+			blocks: func(id notion.Id) (notion.Blocks, error) {
+				return notion.Blocks{
+					{Object: "block", Id: "db", Type: notion.BlockTypeChildDatabase, ChildDatabase: &notion.Child{Title: "db"}},
+					waiting,
+				}, nil
+			},
+			database: func(id notion.Id) (*notion.Database, error) {
+				<-started
+				return nil, fmt.Errorf("%s is broken", id)
+			},
+		},
+		started:  started,
+		canceled: make(chan struct{}),
+	}
+
+	// the failing database cancels fetching the children of its sibling
+	_, _, err := NewConverter(cli, WithConcurrency(2)).Blocks(context.Background(), "page")
+	assert.EqualError(t, err, "db is broken")
+
+	select {
+	case <-cli.canceled:
+	default:
+		t.Error("fetching the children of the sibling was not canceled")
+	}
+}
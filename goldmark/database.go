@@ -3,15 +3,13 @@ package goldmark
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"path/filepath"
-	"sort"
-	"strings"
 
 	"github.com/faetools/go-notion/pkg/notion"
 	n_ast "github.com/faetools/notion-to-goldmark/ast"
 	"github.com/samber/lo"
 	"github.com/yuin/goldmark/ast"
-	"github.com/yuin/goldmark/text"
 
 	extast "github.com/yuin/goldmark/extension/ast"
 )
@@ -39,6 +37,7 @@ var propertyValueTypes = map[notion.PropertyType][]byte{
 	notion.PropertyTypeUrl:            []byte("typesUrl"),
 }
 
+// tableCollector collects the nodes of a database.
 type tableCollector struct {
 	p        *pageCollector
 	root     string
@@ -46,22 +45,20 @@ type tableCollector struct {
 	propKeys []string
 }
 
-func (p *pageCollector) getTable(id notion.Id) (*extast.Table, error) {
+// getDatabase returns the entries of the database as a table,
+// or in the layout of its view if it has one.
+func (p *pageCollector) getDatabase(id notion.Id) (ast.Node, error) {
 	db, err := p.cli.GetNotionDatabase(p.ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	keys := lo.Keys(db.Properties)
+	v := p.views[dashless(db.Id)]
 
-	// the title first, the rest are sorted alphabetically
-	sort.SliceStable(keys, func(i, j int) bool {
-		if db.Properties[keys[j]].Type == notion.PropertyTypeTitle {
-			return false
-		}
-
-		return db.Properties[keys[i]].Type == notion.PropertyTypeTitle || keys[i] < keys[j]
-	})
+	keys, err := propertyKeys(db, v)
+	if err != nil {
+		return nil, err
+	}
 
 	c := &tableCollector{
 		p:        p,
@@ -70,26 +67,40 @@ func (p *pageCollector) getTable(id notion.Id) (*extast.Table, error) {
 		propKeys: keys,
 	}
 
-	table := extast.NewTable()
-	setClasses(table, "", classCollectionContent)
-
-	table.AppendChild(table, c.tableHeader())
-
-	entries, err := p.cli.GetAllDatabaseEntries(p.ctx, id)
+	entries, err := p.getEntries(db, v)
 	if err != nil {
 		return nil, err
 	}
 
-	sort.SliceStable(entries, func(i, j int) bool {
-		t1 := entries[i].Title()
-		t2 := entries[j].Title()
-
-		if t1 == "" {
-			return false
+	switch v.Layout {
+	case "":
+		return c.table(entries)
+	case n_ast.DatabaseLayoutTable:
+		table, err := c.table(entries)
+		if err != nil {
+			return nil, err
 		}
 
-		return t2 == "" || t1 < t2
-	})
+		view := &n_ast.DatabaseView{Layout: v.Layout}
+		view.AppendChild(view, table)
+
+		return view, nil
+	}
+
+	// cards only show the title by default
+	if v.Properties == nil {
+		c.propKeys = nil
+	}
+
+	return c.view(v, entries)
+}
+
+// table returns the entries as rows of a table.
+func (c *tableCollector) table(entries notion.Pages) (*extast.Table, error) {
+	table := extast.NewTable()
+	setClasses(table, "", classCollectionContent)
+
+	table.AppendChild(table, c.tableHeader())
 
 	for _, entry := range entries {
 		row, err := c.tableRow(entry)
@@ -206,7 +217,12 @@ func (c *tableCollector) toNodesPropertyValue(p notion.Page, propName string, pr
 
 	switch prop.Type {
 	case notion.PropertyTypeTitle:
-		n = linkToPage(prop.Title.Content(), p.Id, c.p.root, c.root)
+		n = c.p.linkToPage(PageLink{
+			ID:     p.Id,
+			Title:  prop.Title.Content(),
+			Parent: p.Parent,
+			Dir:    path.Join(c.p.root, c.root),
+		})
 	case notion.PropertyTypeNumber:
 		if prop.Number == nil {
 			return nil, nil
@@ -235,7 +251,12 @@ func (c *tableCollector) toNodesPropertyValue(p notion.Page, propName string, pr
 				nodes[i*2-1] = newString(", ")
 			}
 
-			nodes[i*2] = linkToPage(p.Title(), id, c.p.root, c.root)
+			nodes[i*2] = c.p.linkToPage(PageLink{
+				ID:     id,
+				Title:  p.Title(),
+				Parent: p.Parent,
+				Dir:    path.Join(c.p.root, c.root),
+			})
 		}
 
 		return nodes, nil
@@ -346,35 +367,31 @@ func (c *tableCollector) toNodesPropertyValue(p notion.Page, propName string, pr
 
 			rawURL := f.URL()
 
-			u, _ := url.Parse(rawURL) // TODO don't underscore error
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				u = &url.URL{Path: rawURL}
+			}
 
 			fileName := filepath.Base(u.Path)
 
-			if u.Host == "s3.us-west-2.amazonaws.com" &&
-				strings.HasPrefix(u.Path, "/secure.notion-static.com/") {
-				// TODO download
+			link := newLink("", rawURL)
 
-				rawURL = filepath.Join(c.p.root, c.root, getDir(p.Title(), p.Id), fileName)
+			if f.Type == notion.FileTypeFile {
+				link.Destination = c.p.asset(path.Join(c.p.root, c.root, getDir(p.Title(), p.Id)), rawURL)
 			}
 
-			link := newLink("", rawURL)
-
 			switch filepath.Ext(fileName) {
 			case ".jpg", ".jpeg", ".png":
 				img := ast.NewImage(link)
 				img.SetAttributeString(attrStyle, []byte("width:20px;max-height:24px"))
 
-				// TODO remove in favor of below code
+				// the text is the alternative text of the image
 				if f.Type == notion.FileTypeExternal {
-					img.SetAttributeString("alt", []byte(rawURL))
+					img.AppendChild(img, newString(rawURL))
 				} else {
-					img.SetAttributeString("alt", []byte(fileName))
+					img.AppendChild(img, newString(fileName))
 				}
 
-				txt := ast.NewText()
-				txt.Segment = text.NewSegment(0, 0) // TODO
-				img.AppendChild(img, txt)
-
 				link.AppendChild(link, img)
 			default:
 				link.AppendChild(link, newString(fileName))
@@ -445,6 +462,6 @@ func newURLValue(dest string) *ast.Link {
 
 // HACK
 var customStatus = &notion.SelectValue{
-	Name:  "I&#x27;ve taken a look",
+	Name:  "I've taken a look",
 	Color: notion.ColorBlue,
 }
@@ -0,0 +1,193 @@
+package goldmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/yuin/goldmark/ast"
+)
+
+// defaultConcurrency is the default number of concurrent requests.
+// Notion allows an average of three requests per second.
+const defaultConcurrency = 3
+
+// FetchError is reported if the children of a block could not be fetched.
+type FetchError struct {
+	ID  notion.UUID
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetching children of block %s: %v", e.ID, e.Err)
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// IsNotFound returns whether err is Notion's response for an object that does not exist
+// or is not shared with the integration.
+func IsNotFound(err error) bool {
+	var notionErr *notion.Error
+	return errors.As(err, &notionErr) && notionErr.Status == http.StatusNotFound
+}
+
+// FetchErrors is returned if the children of some blocks could not be fetched.
+type FetchErrors []*FetchError
+
+func (es FetchErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// limitGetter limits the number of concurrent requests of a notion.Getter.
+type limitGetter struct {
+	cli notion.Getter
+	sem chan struct{}
+}
+
+func newLimitGetter(cli notion.Getter, n int) *limitGetter {
+	if n < 1 {
+		n = 1
+	}
+
+	return &limitGetter{cli: cli, sem: make(chan struct{}, n)}
+}
+
+func (g *limitGetter) acquire(ctx context.Context) error {
+	select {
+	case g.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *limitGetter) release() { <-g.sem }
+
+// GetNotionPage implements notion.Getter.
+func (g *limitGetter) GetNotionPage(ctx context.Context, id notion.Id) (*notion.Page, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	return g.cli.GetNotionPage(ctx, id)
+}
+
+// GetAllBlocks implements notion.Getter.
+func (g *limitGetter) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	return g.cli.GetAllBlocks(ctx, id)
+}
+
+// GetNotionDatabase implements notion.Getter.
+func (g *limitGetter) GetNotionDatabase(ctx context.Context, id notion.Id) (*notion.Database, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	return g.cli.GetNotionDatabase(ctx, id)
+}
+
+// GetAllDatabaseEntries implements notion.Getter.
+func (g *limitGetter) GetAllDatabaseEntries(ctx context.Context, id notion.Id) (notion.Pages, error) {
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	return g.cli.GetAllDatabaseEntries(ctx, id)
+}
+
+// GetDatabaseEntries implements DatabaseEntriesGetter.
+// It returns errNoDatabaseEntriesGetter if the underlying getter does not implement it.
+func (g *limitGetter) GetDatabaseEntries(ctx context.Context, id notion.Id, filter *notion.Filter, sorts *notion.Sorts) (notion.Pages, error) {
+	cli, ok := g.cli.(DatabaseEntriesGetter)
+	if !ok {
+		return nil, errNoDatabaseEntriesGetter
+	}
+
+	if err := g.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer g.release()
+
+	return cli.GetDatabaseEntries(ctx, id, filter, sorts)
+}
+
+// toNodesWithChildren converts the blocks with a pool of as many workers as concurrent requests.
+// The nodes are in the order of the blocks.
+//
+// Every level of blocks has its own pool, since the workers of parent blocks wait for those
+// of their children. An error other than FetchErrors stops the conversion of all blocks.
+func (c *blockCollector) toNodesWithChildren(blocks notion.Blocks) ([]ast.Node, error) {
+	nodes := make([]ast.Node, len(blocks))
+	errs := make([]error, len(blocks))
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < c.p.concurrency && w < len(blocks); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				nodes[i], errs[i] = c.toNodeWithChildren(blocks[i])
+
+				if _, ok := errs[i].(FetchErrors); errs[i] != nil && !ok {
+					c.p.fail(errs[i])
+				}
+			}
+		}()
+	}
+
+jobs:
+	for i := range blocks {
+		select {
+		case jobs <- i:
+		case <-c.p.ctx.Done():
+			break jobs
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if err := c.p.ctx.Err(); err != nil {
+		return nil, c.p.failure(err)
+	}
+
+	var fetchErrs FetchErrors
+
+	for _, err := range errs {
+		switch err := err.(type) {
+		case nil:
+		case FetchErrors:
+			fetchErrs = append(fetchErrs, err...)
+		default:
+			return nil, err
+		}
+	}
+
+	if len(fetchErrs) > 0 {
+		return nil, fetchErrs
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,167 @@
+package goldmark
+
+import (
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+)
+
+// A FrontMatterMapper maps the metadata of a page to the fields of its front matter.
+type FrontMatterMapper interface {
+	// FrontMatter returns the fields of the front matter.
+	// Fields with a nil value are left out.
+	FrontMatter(meta *PageMeta) map[string]interface{}
+}
+
+// FrontMatterMapperFunc is an adapter to use a function as a FrontMatterMapper.
+type FrontMatterMapperFunc func(meta *PageMeta) map[string]interface{}
+
+// FrontMatter implements FrontMatterMapper.
+func (f FrontMatterMapperFunc) FrontMatter(meta *PageMeta) map[string]interface{} {
+	return f(meta)
+}
+
+// PropertyMapper is a FrontMatterMapper that maps the properties of a page to fields.
+//
+// The values of the fields are:
+//   - the names of the options of selects, multi-selects and statuses,
+//     e.g. to use a multi-select as tags
+//   - the names of people
+//   - booleans for checkboxes, e.g. as a "published" flag
+//   - dates and times formatted as ISO 8601, with a start and end for date ranges
+//   - the plain text of titles and rich texts
+//   - numbers, URLs, emails and phone numbers as is
+//
+// Other properties and empty values are left out.
+type PropertyMapper struct {
+	// Fields maps the names of properties to the names of their fields.
+	// If it is nil, all properties are mapped to fields of the same name.
+	Fields map[string]string
+}
+
+// FrontMatter implements FrontMatterMapper.
+func (m PropertyMapper) FrontMatter(meta *PageMeta) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	for name, prop := range meta.Properties {
+		field := name
+		if m.Fields != nil {
+			f, ok := m.Fields[name]
+			if !ok {
+				continue
+			}
+
+			field = f
+		}
+
+		if v := frontMatterValue(prop); v != nil {
+			fields[field] = v
+		}
+	}
+
+	return fields
+}
+
+// frontMatterValue returns the value of a property in the front matter, if any.
+func frontMatterValue(prop notion.PropertyValue) interface{} {
+	switch prop.Type {
+	case notion.PropertyTypeTitle:
+		if prop.Title != nil {
+			return nonEmpty(prop.Title.Content())
+		}
+	case notion.PropertyTypeRichText:
+		if prop.RichText != nil {
+			return nonEmpty(prop.RichText.Content())
+		}
+	case notion.PropertyTypeSelect:
+		if prop.Select != nil {
+			return prop.Select.Name
+		}
+	case notion.PropertyTypeStatus:
+		if prop.Status != nil {
+			return prop.Status.Name
+		}
+	case notion.PropertyTypeMultiSelect:
+		if prop.MultiSelect == nil || len(*prop.MultiSelect) == 0 {
+			return nil
+		}
+
+		names := make([]string, len(*prop.MultiSelect))
+		for i, o := range *prop.MultiSelect {
+			names[i] = o.Name
+		}
+
+		return names
+	case notion.PropertyTypePeople:
+		if prop.People == nil {
+			return nil
+		}
+
+		names := []string{}
+		for _, u := range *prop.People {
+			if u.Name != nil {
+				names = append(names, *u.Name)
+			}
+		}
+
+		if len(names) == 0 {
+			return nil
+		}
+
+		return names
+	case notion.PropertyTypeCheckbox:
+		if prop.Checkbox != nil {
+			return *prop.Checkbox
+		}
+	case notion.PropertyTypeDate:
+		switch {
+		case prop.Date == nil:
+		case prop.Date.End == nil:
+			return formatFrontMatterTime(prop.Date.Start)
+		default:
+			return map[string]interface{}{
+				"start": formatFrontMatterTime(prop.Date.Start),
+				"end":   formatFrontMatterTime(*prop.Date.End),
+			}
+		}
+	case notion.PropertyTypeCreatedTime:
+		if prop.CreatedTime != nil {
+			return formatFrontMatterTime(*prop.CreatedTime)
+		}
+	case notion.PropertyTypeNumber:
+		if prop.Number != nil {
+			return float64(*prop.Number)
+		}
+	case notion.PropertyTypeUrl:
+		if prop.Url != nil {
+			return nonEmpty(*prop.Url)
+		}
+	case notion.PropertyTypeEmail:
+		if prop.Email != nil {
+			return nonEmpty(*prop.Email)
+		}
+	case notion.PropertyTypePhoneNumber:
+		if prop.PhoneNumber != nil {
+			return nonEmpty(*prop.PhoneNumber)
+		}
+	}
+
+	return nil
+}
+
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+// formatFrontMatterTime formats dates without a time as dates only.
+func formatFrontMatterTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+
+	return t.Format(time.RFC3339)
+}
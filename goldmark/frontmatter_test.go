@@ -0,0 +1,167 @@
+package goldmark_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestFrontMatter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	published, name, url := true, "Ada", "https://example.com"
+	start := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	title := notion.NewRichTexts("My Post")
+
+	g := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{
+				Id: notion.UUID(id),
+				Properties: notion.PropertyValueMap{
+					"Name": {Type: notion.PropertyTypeTitle, Title: &title},
+					"Tags": {Type: notion.PropertyTypeMultiSelect, MultiSelect: &notion.SelectValues{
+						{Name: "go"}, {Name: "notion"},
+					}},
+					"Category":  {Type: notion.PropertyTypeSelect, Select: &notion.SelectValue{Name: "Blog"}},
+					"Published": {Type: notion.PropertyTypeCheckbox, Checkbox: &published},
+					"Date":      {Type: notion.PropertyTypeDate, Date: &notion.Date{Start: start}},
+					"Authors":   {Type: notion.PropertyTypePeople, People: &[]notion.User{{Name: &name}}},
+					"Link":      {Type: notion.PropertyTypeUrl, Url: &url},
+					"Empty":     {Type: notion.PropertyTypeSelect},
+				},
+			}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{
+				paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts("Hello")}),
+			}, nil
+		},
+	}
+
+	mapper := PropertyMapper{Fields: map[string]string{
+		"Name":      "title",
+		"Tags":      "tags",
+		"Published": "published",
+		"Date":      "date",
+		"Authors":   "authors",
+		"Link":      "url",
+		"Empty":     "empty",
+	}}
+
+	doc, source, err := NewConverter(g, WithFrontMatter(mapper)).Page(ctx, "page")
+	assert.NoError(t, err)
+
+	want := map[string]interface{}{
+		"title":     "My Post",
+		"tags":      []string{"go", "notion"},
+		"published": true,
+		"date":      "2022-07-01",
+		"authors":   []string{"Ada"},
+		"url":       "https://example.com",
+	}
+	assert.Equal(t, want, doc.Meta())
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+		assert.Equal(t, `---
+authors:
+- Ada
+date: "2022-07-01"
+published: true
+tags:
+- go
+- notion
+title: My Post
+url: https://example.com
+---
+
+Hello
+`, w.String())
+
+		// goldmark-meta reads the same metadata
+		md := goldmark.New(goldmark.WithExtensions(meta.New(meta.WithStoresInDocument())))
+		parsed := md.Parser().Parse(text.NewReader(w.Bytes()), parser.WithContext(parser.NewContext()))
+		assert.Equal(t, map[string]interface{}{
+			"title":     "My Post",
+			"tags":      []interface{}{"go", "notion"},
+			"published": true,
+			"date":      "2022-07-01",
+			"authors":   []interface{}{"Ada"},
+			"url":       "https://example.com",
+		}, parsed.OwnerDocument().Meta())
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		t.Parallel()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New(markdown.WithFrontMatterFormat(markdown.TOML)).Render(w, source, doc))
+		assert.Equal(t, `+++
+authors = ["Ada"]
+date = "2022-07-01"
+published = true
+tags = ["go", "notion"]
+title = "My Post"
+url = "https://example.com"
++++
+
+Hello
+`, w.String())
+	})
+
+	t.Run("all properties", func(t *testing.T) {
+		t.Parallel()
+
+		fields := PropertyMapper{}.FrontMatter(GetPageMeta(doc))
+		assert.Equal(t, "Blog", fields["Category"])
+		assert.Equal(t, "My Post", fields["Name"])
+		assert.NotContains(t, fields, "Empty")
+	})
+
+	t.Run("without front matter", func(t *testing.T) {
+		t.Parallel()
+
+		doc, source, err := NewConverter(g).Page(ctx, "page")
+		assert.NoError(t, err)
+		assert.Empty(t, doc.Meta())
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+		assert.Equal(t, "Hello\n", w.String())
+	})
+}
+
+func TestPropertyMapper_Dates(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2022, 7, 1, 14, 30, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	fields := PropertyMapper{}.FrontMatter(&PageMeta{Properties: notion.PropertyValueMap{
+		"Event":   {Type: notion.PropertyTypeDate, Date: &notion.Date{Start: start, End: &end}},
+		"Created": {Type: notion.PropertyTypeCreatedTime, CreatedTime: &start},
+	}})
+
+	assert.Equal(t, map[string]interface{}{
+		"Event": map[string]interface{}{
+			"start": "2022-07-01T14:30:00Z",
+			"end":   "2022-07-01T16:30:00Z",
+		},
+		"Created": "2022-07-01T14:30:00Z",
+	}, fields)
+}
@@ -0,0 +1,86 @@
+package goldmark
+
+import "github.com/faetools/go-notion/pkg/notion"
+
+// chromaLexers maps the languages of Notion code blocks to the names of Chroma lexers.
+var chromaLexers = map[notion.CodeLanguage]string{
+	notion.CodeLanguageAbap:         "abap",
+	notion.CodeLanguageArduino:      "arduino",
+	notion.CodeLanguageBash:         "bash",
+	notion.CodeLanguageBasic:        "qbasic",
+	notion.CodeLanguageC:            "c",
+	notion.CodeLanguageC1:           "cpp",
+	notion.CodeLanguageC2:           "csharp",
+	notion.CodeLanguageClojure:      "clojure",
+	notion.CodeLanguageCoffeescript: "coffeescript",
+	notion.CodeLanguageCss:          "css",
+	notion.CodeLanguageDart:         "dart",
+	notion.CodeLanguageDiff:         "diff",
+	notion.CodeLanguageDocker:       "docker",
+	notion.CodeLanguageElixir:       "elixir",
+	notion.CodeLanguageElm:          "elm",
+	notion.CodeLanguageErlang:       "erlang",
+	notion.CodeLanguageF:            "fsharp",
+	notion.CodeLanguageFlow:         "javascript",
+	notion.CodeLanguageFortran:      "fortran",
+	notion.CodeLanguageGherkin:      "gherkin",
+	notion.CodeLanguageGlsl:         "glsl",
+	notion.CodeLanguageGo:           "go",
+	notion.CodeLanguageGraphql:      "graphql",
+	notion.CodeLanguageGroovy:       "groovy",
+	notion.CodeLanguageHaskell:      "haskell",
+	notion.CodeLanguageHtml:         "html",
+	notion.CodeLanguageJava:         "java",
+	notion.CodeLanguageJavaccc:      "java",
+	notion.CodeLanguageJavascript:   "javascript",
+	notion.CodeLanguageJson:         "json",
+	notion.CodeLanguageJulia:        "julia",
+	notion.CodeLanguageKotlin:       "kotlin",
+	notion.CodeLanguageLatex:        "tex",
+	notion.CodeLanguageLess:         "css",
+	notion.CodeLanguageLisp:         "common-lisp",
+	notion.CodeLanguageLivescript:   "livescript",
+	notion.CodeLanguageLua:          "lua",
+	notion.CodeLanguageMakefile:     "makefile",
+	notion.CodeLanguageMarkdown:     "markdown",
+	notion.CodeLanguageMarkup:       "html",
+	notion.CodeLanguageMatlab:       "matlab",
+	notion.CodeLanguageMermaid:      "mermaid",
+	notion.CodeLanguageNix:          "nix",
+	notion.CodeLanguageObjectiveC:   "objective-c",
+	notion.CodeLanguageOcaml:        "ocaml",
+	notion.CodeLanguagePascal:       "pascal",
+	notion.CodeLanguagePerl:         "perl",
+	notion.CodeLanguagePhp:          "php",
+	notion.CodeLanguagePlainText:    "plaintext",
+	notion.CodeLanguagePowershell:   "powershell",
+	notion.CodeLanguageProlog:       "prolog",
+	notion.CodeLanguageProtobuf:     "protobuf",
+	notion.CodeLanguagePython:       "python",
+	notion.CodeLanguageR:            "r",
+	notion.CodeLanguageReason:       "reasonml",
+	notion.CodeLanguageRuby:         "ruby",
+	notion.CodeLanguageRust:         "rust",
+	notion.CodeLanguageSass:         "sass",
+	notion.CodeLanguageScala:        "scala",
+	notion.CodeLanguageScheme:       "scheme",
+	notion.CodeLanguageScss:         "scss",
+	notion.CodeLanguageShell:        "bash",
+	notion.CodeLanguageSql:          "sql",
+	notion.CodeLanguageSwift:        "swift",
+	notion.CodeLanguageTypescript:   "typescript",
+	notion.CodeLanguageVbNet:        "vb.net",
+	notion.CodeLanguageVerilog:      "verilog",
+	notion.CodeLanguageVhdl:         "vhdl",
+	notion.CodeLanguageVisualBasic:  "vb.net",
+	notion.CodeLanguageWebassembly:  "wat",
+	notion.CodeLanguageXml:          "xml",
+	notion.CodeLanguageYaml:         "yaml",
+}
+
+// ChromaLexer returns the name of the Chroma lexer for the language of a Notion code block.
+// It is the info string of the converted fenced code blocks.
+// For unknown languages, it is empty.
+func ChromaLexer(lang notion.CodeLanguage) string {
+	return chromaLexers[lang]
+}
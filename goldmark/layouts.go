@@ -0,0 +1,199 @@
+package goldmark
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// view returns the entries as cards in the layout of the view.
+func (c *tableCollector) view(v DatabaseView, entries notion.Pages) (*n_ast.DatabaseView, error) {
+	view := &n_ast.DatabaseView{Layout: v.Layout}
+
+	switch v.Layout {
+	case n_ast.DatabaseLayoutBoard:
+		return view, c.board(view, v.GroupBy, entries)
+	case n_ast.DatabaseLayoutCalendar:
+		return view, c.calendar(view, v.CalendarBy, entries)
+	case n_ast.DatabaseLayoutGallery, n_ast.DatabaseLayoutList:
+		for _, entry := range entries {
+			card, err := c.card(entry, v.Layout == n_ast.DatabaseLayoutGallery)
+			if err != nil {
+				return nil, err
+			}
+
+			view.AppendChild(view, card)
+		}
+
+		return view, nil
+	default:
+		return nil, fmt.Errorf("unknown database layout %q", v.Layout)
+	}
+}
+
+// board groups the cards by the options of a select or status property.
+// Like in Notion, entries without an option come first and
+// the options are in the order of the database schema.
+func (c *tableCollector) board(view *n_ast.DatabaseView, groupBy string, entries notion.Pages) error {
+	meta, ok := c.props[groupBy]
+	if !ok || (meta.Type != notion.PropertyTypeSelect && meta.Type != notion.PropertyTypeStatus) {
+		return fmt.Errorf("database has no select or status property %q to group by", groupBy)
+	}
+
+	empty := &n_ast.DatabaseGroup{Value: &notion.SelectValue{Name: "No " + groupBy, Color: notion.ColorDefault}}
+	view.AppendChild(view, empty)
+
+	groups := map[string]*n_ast.DatabaseGroup{}
+
+	group := func(val *notion.SelectValue) *n_ast.DatabaseGroup {
+		if g, ok := groups[val.Name]; ok {
+			return g
+		}
+
+		g := &n_ast.DatabaseGroup{Value: val}
+		groups[val.Name] = g
+		view.AppendChild(view, g)
+
+		return g
+	}
+
+	options := meta.Select
+	if meta.Type == notion.PropertyTypeStatus {
+		options = statusOptions(meta)
+	}
+
+	if options != nil {
+		for i := range options.Options {
+			group(&options.Options[i])
+		}
+	}
+
+	for _, entry := range entries {
+		card, err := c.card(entry, false)
+		if err != nil {
+			return err
+		}
+
+		g := empty
+
+		switch prop := entry.Properties[groupBy]; {
+		case prop.Select != nil:
+			g = group(prop.Select)
+		case prop.Status != nil:
+			g = group(prop.Status)
+		}
+
+		g.AppendChild(g, card)
+	}
+
+	if !empty.HasChildren() {
+		view.RemoveChild(view, empty)
+	}
+
+	return nil
+}
+
+// calendar groups the cards by the day their date property starts on.
+// Entries without a date are left out, like in Notion.
+func (c *tableCollector) calendar(view *n_ast.DatabaseView, calendarBy string, entries notion.Pages) error {
+	meta, ok := c.props[calendarBy]
+	if !ok || (meta.Type != notion.PropertyTypeDate && meta.Type != notion.PropertyTypeCreatedTime) {
+		return fmt.Errorf("database has no date property %q to show a calendar by", calendarBy)
+	}
+
+	groups := map[string]*n_ast.DatabaseGroup{}
+	days := []*n_ast.DatabaseGroup{}
+
+	for _, entry := range entries {
+		var start time.Time
+
+		switch prop := entry.Properties[calendarBy]; {
+		case prop.Date != nil:
+			start = prop.Date.Start
+		case prop.CreatedTime != nil:
+			start = *prop.CreatedTime
+		default:
+			continue
+		}
+
+		card, err := c.card(entry, false)
+		if err != nil {
+			return err
+		}
+
+		day := start.Format("2006-01-02")
+
+		g, ok := groups[day]
+		if !ok {
+			g = &n_ast.DatabaseGroup{Day: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())}
+			groups[day] = g
+			days = append(days, g)
+		}
+
+		g.AppendChild(g, card)
+	}
+
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Day.Before(days[j].Day) })
+
+	for _, g := range days {
+		view.AppendChild(view, g)
+	}
+
+	return nil
+}
+
+// card returns the card of the entry with a link to it and its visible properties.
+func (c *tableCollector) card(entry notion.Page, withCover bool) (*n_ast.DatabaseCard, error) {
+	n := &n_ast.DatabaseCard{ID: entry.Id}
+	n.SetAttributeString(attrID, []byte(entry.Id))
+
+	dir := path.Join(c.p.root, c.root)
+
+	if withCover {
+		switch {
+		case entry.Cover == nil:
+		case entry.Cover.Type == notion.FileTypeFile:
+			n.Cover = string(c.p.asset(path.Join(dir, getDir(entry.Title(), entry.Id)), entry.Cover.URL()))
+		default:
+			n.Cover = string(util.URLEscape([]byte(entry.Cover.URL()), true))
+		}
+	}
+
+	n.AppendChild(n, c.p.linkToPage(PageLink{
+		ID:     entry.Id,
+		Title:  entry.Title(),
+		Parent: entry.Parent,
+		Dir:    dir,
+	}))
+
+	for _, name := range c.propKeys {
+		if c.props[name].Type == notion.PropertyTypeTitle {
+			continue
+		}
+
+		nodes, err := c.toNodesPropertyValue(entry, name, entry.Properties[name])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(nodes) == 0 {
+			continue
+		}
+
+		prop := &n_ast.DatabaseCardProperty{Name: name}
+		setClasses(prop, "", classCardProperty)
+
+		for _, node := range nodes {
+			prop.AppendChild(prop, node)
+		}
+
+		n.AppendChild(n, prop)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,204 @@
+package goldmark_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+func convertView(t *testing.T, cli notion.Getter, v DatabaseView) (*n_ast.DatabaseView, []byte, error) {
+	t.Helper()
+
+	doc, source, err := NewConverter(cli, WithDatabaseView(databaseID, v)).Database(context.Background(), databaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	view, _ := doc.FirstChild().(*n_ast.DatabaseView)
+	assert.NotNil(t, view)
+
+	return view, source, nil
+}
+
+// cardIDs returns the IDs of the entries of the cards.
+func cardIDs(n ast.Node) []string {
+	ids := []string{}
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		ids = append(ids, string(c.(*n_ast.DatabaseCard).ID))
+	}
+
+	return ids
+}
+
+func TestDatabaseLayouts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("table", func(t *testing.T) {
+		t.Parallel()
+
+		view, _, err := convertView(t, fakeGetter(t), DatabaseView{Layout: n_ast.DatabaseLayoutTable})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, view.ChildCount())
+		assert.Equal(t, []string{entry1, entry2, entry3, untitled}, rowIDs(view.FirstChild().(*extast.Table)))
+	})
+
+	t.Run("board", func(t *testing.T) {
+		t.Parallel()
+
+		view, source, err := convertView(t, fakeGetter(t), DatabaseView{
+			Layout:     n_ast.DatabaseLayoutBoard,
+			GroupBy:    "select",
+			Properties: []string{"A number"},
+		})
+		assert.NoError(t, err)
+
+		names := []string{}
+		groups := [][]string{}
+
+		for g := view.FirstChild(); g != nil; g = g.NextSibling() {
+			names = append(names, g.(*n_ast.DatabaseGroup).Value.Name)
+			groups = append(groups, cardIDs(g))
+		}
+
+		// entries without an option first, then the options in the order of the schema
+		assert.Equal(t, []string{"No select", "bar", "foo"}, names)
+		assert.Equal(t, [][]string{{entry3, untitled}, {entry2}, {entry1}}, groups)
+
+		card := view.LastChild().FirstChild()
+		assert.Equal(t, 2, card.ChildCount())
+		assert.Equal(t, "A number", card.LastChild().(*n_ast.DatabaseCardProperty).Name)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, view))
+
+		got := w.String()
+		assert.Contains(t, got, `<div class="collection-view collection-board">`)
+		assert.Contains(t, got, `<div class="collection-group board-column"><h4><span class="selected-value">No select</span></h4>`)
+		assert.Contains(t, got, `<div class="collection-group board-column"><h4><span class="selected-value select-value-color-blue">bar</span></h4><div id="`+entry2+`" class="collection-card">`)
+		assert.Contains(t, got, `<span class="collection-card-property">31.3</span>`)
+
+		// status options are in the order of the database schema as well
+		view, _, err = convertView(t, statusGetter(t), DatabaseView{Layout: n_ast.DatabaseLayoutBoard, GroupBy: "Status"})
+		assert.NoError(t, err)
+
+		names, groups = nil, nil
+
+		for g := view.FirstChild(); g != nil; g = g.NextSibling() {
+			names = append(names, g.(*n_ast.DatabaseGroup).Value.Name)
+			groups = append(groups, cardIDs(g))
+		}
+
+		assert.Equal(t, []string{"No Status", "Not started", "no idea", "Done"}, names)
+		assert.Equal(t, [][]string{{entry1, untitled}, {}, {entry2}, {entry3}}, groups)
+
+		_, _, err = convertView(t, fakeGetter(t), DatabaseView{Layout: n_ast.DatabaseLayoutBoard, GroupBy: "A number"})
+		assert.EqualError(t, err, `database has no select or status property "A number" to group by`)
+	})
+
+	t.Run("gallery", func(t *testing.T) {
+		t.Parallel()
+
+		g := fakeGetter(t)
+		entries := g.databaseEntries
+		g.databaseEntries = func(id notion.Id) (notion.Pages, error) {
+			pages, err := entries(id)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range pages {
+				if pages[i].Id == entry1 {
+					pages[i].Cover = &notion.File{
+						Type:     notion.FileTypeExternal,
+						External: &notion.ExternalFile{Url: "https://example.com/cover.png"},
+					}
+				}
+			}
+
+			return pages, nil
+		}
+
+		view, source, err := convertView(t, g, DatabaseView{Layout: n_ast.DatabaseLayoutGallery})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{entry1, entry2, entry3, untitled}, cardIDs(view))
+
+		// cards only show the title by default
+		assert.Equal(t, 1, view.FirstChild().ChildCount())
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, view))
+
+		assert.Contains(t, w.String(), `<div id="`+entry1+`" class="collection-card"><img class="collection-card-cover" src="https://example.com/cover.png"/>`)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		view, source, err := convertView(t, fakeGetter(t), DatabaseView{
+			Layout:     n_ast.DatabaseLayoutList,
+			Properties: []string{"select"},
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{entry1, entry2, entry3, untitled}, cardIDs(view))
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, view))
+
+		got := w.String()
+		assert.Contains(t, got, `<ul class="collection-view collection-list"><li id="`+entry1+`" class="collection-list-item">`)
+
+		w.Reset()
+		assert.NoError(t, markdown.New().Render(w, source, view))
+
+		assert.Contains(t, w.String(), " · foo\n")
+	})
+
+	t.Run("calendar", func(t *testing.T) {
+		t.Parallel()
+
+		view, source, err := convertView(t, fakeGetter(t), DatabaseView{
+			Layout:     n_ast.DatabaseLayoutCalendar,
+			CalendarBy: "some date",
+		})
+		assert.NoError(t, err)
+
+		days := []string{}
+		groups := [][]string{}
+
+		for g := view.FirstChild(); g != nil; g = g.NextSibling() {
+			days = append(days, g.(*n_ast.DatabaseGroup).Day.Format("2006-01-02"))
+			groups = append(groups, cardIDs(g))
+		}
+
+		// entries without a date are left out
+		assert.Equal(t, []string{"2022-07-30", "2022-08-05"}, days)
+		assert.Equal(t, [][]string{{entry1}, {entry2}}, groups)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, view))
+
+		assert.Contains(t, w.String(), `<div class="collection-group calendar-day"><h4><time datetime="2022-07-30">July 30, 2022</time></h4>`)
+
+		_, _, err = convertView(t, fakeGetter(t), DatabaseView{Layout: n_ast.DatabaseLayoutCalendar, CalendarBy: "select"})
+		assert.EqualError(t, err, `database has no date property "select" to show a calendar by`)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := convertView(t, fakeGetter(t), DatabaseView{Layout: "timeline"})
+		assert.EqualError(t, err, `unknown database layout "timeline"`)
+	})
+}
@@ -0,0 +1,98 @@
+package goldmark
+
+import (
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/faetools/go-notion/pkg/notion"
+)
+
+// PageLink describes a link to a Notion page or database.
+type PageLink struct {
+	ID    notion.UUID
+	Title string
+	// Parent is the parent of the page, if known.
+	Parent *notion.Parent
+	// Dir is the directory the page is in if exported like Notion does,
+	// relative to the directory of the converted page.
+	Dir string
+	// Outside is set if the page is not part of the converted page,
+	// e.g. a page mentioned from somewhere else in the workspace.
+	// Dir is empty in that case.
+	Outside bool
+}
+
+// A LinkResolver returns the destinations of links to Notion pages.
+// It is called concurrently while the blocks are converted.
+type LinkResolver interface {
+	ResolveLink(l PageLink) string
+}
+
+// LinkResolverFunc is an adapter to use a function as a LinkResolver.
+type LinkResolverFunc func(l PageLink) string
+
+// ResolveLink implements LinkResolver.
+func (f LinkResolverFunc) ResolveLink(l PageLink) string { return f(l) }
+
+// NotionExportLinks links to the HTML files of Notion's export.
+// Like in the export, pages outside of the converted page link to Notion.
+var NotionExportLinks LinkResolver = LinkResolverFunc(func(l PageLink) string {
+	if l.Outside {
+		return notionURL(l)
+	}
+
+	return path.Join(l.Dir, FileName(l.Title, l.ID)+".html")
+})
+
+// SlugLinks links to prefix followed by the slug of the page title,
+// e.g. "/docs/getting-started" for a page titled "Getting Started".
+// The titles of the pages need to be unique for the links to be.
+func SlugLinks(prefix string) LinkResolver {
+	return LinkResolverFunc(func(l PageLink) string {
+		return prefix + strings.ToLower(slug(l.Title))
+	})
+}
+
+// IDLinks links to prefix followed by the ID of the page without dashes.
+func IDLinks(prefix string) LinkResolver {
+	return LinkResolverFunc(func(l PageLink) string {
+		return prefix + dashless(l.ID)
+	})
+}
+
+// notionURL returns the URL of the page on Notion.
+func notionURL(l PageLink) string {
+	if s := slug(l.Title); s != "" {
+		return "https://www.notion.so/" + s + "-" + dashless(l.ID)
+	}
+
+	return "https://www.notion.so/" + dashless(l.ID)
+}
+
+// slug joins the words of the title with dashes, e.g. "Getting-Started".
+func slug(title string) string {
+	return strings.Join(strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+}
+
+// FileName returns the name of the file or directory of a page or database in Notion's export,
+// without an extension, e.g. "Example Page 96245c8f178444a482ad1941127c3ec3".
+// Like Notion, slashes and leading dots are removed from the title,
+// so the name cannot point to another directory, and untitled pages are called "Untitled".
+func FileName(title string, id notion.UUID) string {
+	name := strings.TrimLeft(pathSeparators.Replace(title), ". ")
+	if name == "" {
+		name = "Untitled"
+	}
+
+	return name + " " + dashless(id)
+}
+
+// pathSeparators replaces the separators of paths in titles.
+var pathSeparators = strings.NewReplacer("/", " ", `\`, " ")
+
+func dashless(id notion.UUID) string {
+	return strings.ReplaceAll(string(id), "-", "")
+}
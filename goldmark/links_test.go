@@ -0,0 +1,151 @@
+package goldmark_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkResolvers(t *testing.T) {
+	t.Parallel()
+
+	inside := PageLink{
+		ID:    "2633808e-7e36-4f4e-972a-ccd2d3c49004",
+		Title: "My child page",
+		Dir:   "Example Page 96245c8f178444a482ad1941127c3ec3",
+	}
+
+	outside := PageLink{
+		ID:      "df90220c-36b9-4024-bb8e-5297d2affae3",
+		Title:   "Getting Started",
+		Outside: true,
+	}
+
+	for _, tt := range []struct {
+		name     string
+		resolver LinkResolver
+		link     PageLink
+		want     string
+	}{
+		{
+			"notion export", NotionExportLinks, inside,
+			"Example Page 96245c8f178444a482ad1941127c3ec3/My child page 2633808e7e364f4e972accd2d3c49004.html",
+		},
+		{
+			"notion export outside", NotionExportLinks, outside,
+			"https://www.notion.so/Getting-Started-df90220c36b94024bb8e5297d2affae3",
+		},
+		{"slug", SlugLinks("/docs/"), inside, "/docs/my-child-page"},
+		{"slug outside", SlugLinks("/docs/"), outside, "/docs/getting-started"},
+		{"id", IDLinks("/p/"), inside, "/p/2633808e7e364f4e972accd2d3c49004"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.resolver.ResolveLink(tt.link))
+		})
+	}
+}
+
+func TestFileName(t *testing.T) {
+	t.Parallel()
+
+	id := notion.UUID("2633808e-7e36-4f4e-972a-ccd2d3c49004")
+
+	for title, want := range map[string]string{
+		"My child page": "My child page 2633808e7e364f4e972accd2d3c49004",
+		"../Q1/Q2 plan": "Q1 Q2 plan 2633808e7e364f4e972accd2d3c49004",
+		"":              "Untitled 2633808e7e364f4e972accd2d3c49004",
+		"..":            "Untitled 2633808e7e364f4e972accd2d3c49004",
+	} {
+		assert.Equal(t, want, FileName(title, id), title)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	blocks, err := cli.GetAllBlocks(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	// only convert the link to the page itself and the mentions of the child page and database
+	g := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) { return cli.GetNotionPage(ctx, id) },
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{blocks[54], blocks[56], blocks[64]}, nil
+		},
+		database: func(id notion.Id) (*notion.Database, error) { return cli.GetNotionDatabase(ctx, id) },
+	}
+
+	root := pageRoot("Example Page", fake.PageID)
+
+	t.Run("notion export", func(t *testing.T) {
+		t.Parallel()
+
+		doc, source, err := NewConverter(g).Page(ctx, fake.PageID)
+		assert.NoError(t, err)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, doc))
+		assert.Equal(t, `<figure id="96245c8f-1784-44a4-82ad-1941127c3ec3" class="link-to-page">`+
+			`<a href="Example%20Page%2096245c8f178444a482ad1941127c3ec3.html"><span class="icon">🌄</span>Example Page</a></figure>`+
+			`<p id="893ab5b3-e792-480a-8bc2-17d8e5b39025" class="">`+
+			`<a href="Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20Child%20Database%207a3c647e4c1e4c27bf1dcfb0105e55ce.html">My Child Database</a> </p>`+
+			`<p id="6295f354-a432-49f7-ad1e-b211569de18d" class="">`+
+			`<a href="Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20child%20page%202633808e7e364f4e972accd2d3c49004.html">My child page</a>`+
+			` was already mentioned.</p>`, w.String())
+
+		w.Reset()
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+		assert.Equal(t, "[Example Page](Example%20Page%2096245c8f178444a482ad1941127c3ec3.md)\n\n"+
+			"[My Child Database](Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20Child%20Database%207a3c647e4c1e4c27bf1dcfb0105e55ce.md)\n\n"+
+			"[My child page](Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20child%20page%202633808e7e364f4e972accd2d3c49004.md) was already mentioned.\n",
+			w.String())
+	})
+
+	t.Run("ids", func(t *testing.T) {
+		t.Parallel()
+
+		var mu sync.Mutex
+
+		links := map[notion.UUID]PageLink{}
+
+		nodes, err := GetPage(ctx, g, fake.PageID, -1, WithLinkResolver(LinkResolverFunc(func(l PageLink) string {
+			mu.Lock()
+			defer mu.Unlock()
+
+			links[l.ID] = l
+
+			return IDLinks("/").ResolveLink(l)
+		})))
+		assert.NoError(t, err)
+		assert.Len(t, nodes, 3)
+
+		if assert.Len(t, links, 3) {
+			page := links[notion.UUID(fake.PageID)]
+			assert.Equal(t, "Example Page", page.Title)
+			assert.Equal(t, "", page.Dir)
+
+			db := links["7a3c647e-4c1e-4c27-bf1d-cfb0105e55ce"]
+			assert.Equal(t, notion.ParentTypePageId, db.Parent.Type)
+			assert.Equal(t, "My Child Database", db.Title)
+
+			child := links["2633808e-7e36-4f4e-972a-ccd2d3c49004"]
+			assert.Equal(t, notion.ParentTypePageId, child.Parent.Type)
+			assert.Equal(t, root, child.Dir)
+			assert.False(t, child.Outside)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package goldmark
+
+import (
+	"path"
+
+	"github.com/faetools/go-notion/pkg/notion"
+)
+
+// location describes where a page or database ends up if exported like Notion does.
+type location struct {
+	title  string
+	icon   *notion.Icon
+	parent *notion.Parent
+
+	// dir is the URL-escaped directory of the page,
+	// relative to the directory of the converted page
+	dir string
+	// inside is set if the page is part of the converted page
+	inside bool
+}
+
+// pageLink returns the link to the page or database at the location.
+func (l *location) pageLink(id notion.UUID) PageLink {
+	return PageLink{ID: id, Title: l.title, Parent: l.parent, Dir: l.dir, Outside: !l.inside}
+}
+
+// locatePage returns the location of a page, fetching its ancestors as needed.
+func (p *pageCollector) locatePage(id notion.UUID) (*location, error) {
+	if l, ok := p.location(id); ok {
+		return l, nil
+	}
+
+	pg, err := p.cli.GetNotionPage(p.ctx, notion.Id(id))
+	if err != nil {
+		return nil, err
+	}
+
+	l := &location{title: pg.Title(), icon: pg.Icon, parent: pg.Parent}
+
+	l.dir, l.inside, err = p.locateChildren(pg.Parent)
+	if err != nil {
+		return nil, err
+	}
+
+	p.setLocation(id, l)
+
+	return l, nil
+}
+
+// locateDatabase returns the location of a database, fetching its ancestors as needed.
+func (p *pageCollector) locateDatabase(id notion.UUID) (*location, error) {
+	if l, ok := p.location(id); ok {
+		return l, nil
+	}
+
+	db, err := p.cli.GetNotionDatabase(p.ctx, notion.Id(id))
+	if err != nil {
+		return nil, err
+	}
+
+	l := &location{title: db.Title.Content(), icon: db.Icon, parent: db.Parent}
+
+	l.dir, l.inside, err = p.locateChildren(db.Parent)
+	if err != nil {
+		return nil, err
+	}
+
+	p.setLocation(id, l)
+
+	return l, nil
+}
+
+// locateChildren returns the directory of the children of parent
+// and whether they are part of the converted page.
+func (p *pageCollector) locateChildren(parent *notion.Parent) (string, bool, error) {
+	if parent == nil {
+		return "", false, nil
+	}
+
+	var (
+		l   *location
+		id  notion.UUID
+		err error
+	)
+
+	switch {
+	case parent.Type == notion.ParentTypePageId && parent.PageId != nil:
+		id = *parent.PageId
+		l, err = p.locatePage(id)
+	case parent.Type == notion.ParentTypeDatabaseId && parent.DatabaseId != nil:
+		id = *parent.DatabaseId
+		l, err = p.locateDatabase(id)
+	default:
+		// the workspace or a block, which cannot be fetched with a notion.Getter
+		return "", false, nil
+	}
+
+	if err != nil || !l.inside {
+		return "", false, err
+	}
+
+	return path.Join(l.dir, getDir(l.title, id)), true, nil
+}
+
+func (p *pageCollector) location(id notion.UUID) (*location, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l, ok := p.locations[id]
+
+	return l, ok
+}
+
+func (p *pageCollector) setLocation(id notion.UUID, l *location) {
+	p.mu.Lock()
+	p.locations[id] = l
+	p.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package goldmark
+
+import (
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// attrPageMeta is the attribute of the document that holds the metadata of its page.
+const attrPageMeta = "notion-page-meta"
+
+// PageMeta is the metadata of a converted Notion page.
+type PageMeta struct {
+	ID             notion.UUID
+	Title          string
+	URL            string
+	Icon           *notion.Icon
+	Cover          *notion.File
+	Parent         *notion.Parent
+	CreatedTime    time.Time
+	LastEditedTime time.Time
+	Archived       bool
+	Properties     notion.PropertyValueMap
+}
+
+func newPageMeta(p *notion.Page) *PageMeta {
+	m := &PageMeta{
+		ID:             p.Id,
+		Title:          p.Title(),
+		URL:            p.Url,
+		Icon:           p.Icon,
+		Cover:          p.Cover,
+		Parent:         p.Parent,
+		LastEditedTime: p.LastEditedTime,
+		Archived:       p.Archived,
+		Properties:     p.Properties,
+	}
+
+	if p.CreatedTime != nil {
+		m.CreatedTime = *p.CreatedTime
+	}
+
+	return m
+}
+
+// GetPageMeta returns the metadata of the page a document was converted from.
+// It is nil if the document is not a converted page.
+func GetPageMeta(doc *ast.Document) *PageMeta {
+	v, ok := doc.AttributeString(attrPageMeta)
+	if !ok {
+		return nil
+	}
+
+	m, _ := v.(*PageMeta)
+
+	return m
+}
+
+// toNodePageHeader returns the header with the cover, icon and title of the page.
+func (p *pageCollector) toNodePageHeader(page *notion.Page) ast.Node {
+	n := &n_ast.PageHeader{}
+
+	switch {
+	case page.Cover == nil:
+	case page.Cover.Type == notion.FileTypeFile:
+		n.Cover = string(p.asset(p.root, page.Cover.URL()))
+	default:
+		n.Cover = string(util.URLEscape([]byte(page.Cover.URL()), true))
+	}
+
+	if page.Icon != nil {
+		icon := n_ast.NewIcon(*page.Icon)
+		n.AppendChild(n, icon)
+
+		if img, ok := icon.FirstChild().(*ast.Image); ok && page.Icon.Type == notion.IconTypeFile {
+			img.Destination = p.asset(p.root, page.Icon.URL())
+		}
+	}
+
+	title := ast.NewHeading(1)
+	title.SetAttributeString(attrClass, classPageTitle)
+	n.AppendChild(n, title)
+
+	for _, prop := range page.Properties {
+		if prop.Title != nil {
+			appendRichTexts(title, *prop.Title)
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,206 @@
+package goldmark
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+	"github.com/tdewolff/parse/v2"
+	nhtml "github.com/tdewolff/parse/v2/html"
+)
+
+// maxMetadataSize is the number of bytes of a page that are searched for metadata.
+const maxMetadataSize = 1 << 20
+
+// Metadata describes the page a bookmark links to.
+// Favicon and Image are absolute URLs.
+type Metadata struct {
+	Title       string
+	Description string
+	Favicon     string
+	Image       string
+}
+
+// A MetadataProvider returns the metadata of the pages bookmarks link to,
+// which Notion does not hand out via its API.
+// It is called concurrently while the blocks are converted.
+type MetadataProvider interface {
+	Metadata(ctx context.Context, rawURL string) (*Metadata, error)
+}
+
+// HTMLMetadataProvider is a MetadataProvider that fetches the pages
+// and reads the metadata from their HTML head, preferring Open Graph tags.
+// Each page is only fetched once.
+type HTMLMetadataProvider struct {
+	cli *http.Client
+
+	mu    sync.Mutex
+	pages map[string]*metadataCall
+}
+
+type metadataCall struct {
+	done chan struct{}
+	m    *Metadata
+	err  error
+}
+
+// NewHTMLMetadataProvider returns a new metadata provider that fetches the pages with cli.
+// If cli is nil, http.DefaultClient is used.
+func NewHTMLMetadataProvider(cli *http.Client) *HTMLMetadataProvider {
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	return &HTMLMetadataProvider{cli: cli, pages: map[string]*metadataCall{}}
+}
+
+// Metadata implements MetadataProvider.
+func (p *HTMLMetadataProvider) Metadata(ctx context.Context, rawURL string) (*Metadata, error) {
+	p.mu.Lock()
+
+	if c, ok := p.pages[rawURL]; ok {
+		p.mu.Unlock()
+		<-c.done
+
+		return c.m, c.err
+	}
+
+	c := &metadataCall{done: make(chan struct{})}
+	p.pages[rawURL] = c
+
+	p.mu.Unlock()
+
+	c.m, c.err = p.fetch(ctx, rawURL)
+	close(c.done)
+
+	return c.m, c.err
+}
+
+func (p *HTMLMetadataProvider) fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := p.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "" && mt != "text/html" {
+		// e.g. an image or a PDF, which has no metadata
+		return &Metadata{}, nil
+	}
+
+	// the URL after any redirects
+	return parseMetadata(io.LimitReader(resp.Body, maxMetadataSize), resp.Request.URL), nil
+}
+
+// parseMetadata reads the metadata from the head of an HTML page.
+// Relative URLs are resolved against base.
+func parseMetadata(r io.Reader, base *url.URL) *Metadata {
+	var (
+		m               = &Metadata{}
+		title, ogTitle  string
+		desc, ogDesc    string
+		tag             string
+		attrs           map[string]string
+		inTitle, inHead = false, true
+	)
+
+	l := nhtml.NewLexer(parse.NewInput(r))
+
+	for inHead {
+		tt, _ := l.Next()
+
+		switch tt {
+		case nhtml.ErrorToken:
+			inHead = false
+		case nhtml.StartTagToken:
+			tag, attrs = string(l.Text()), map[string]string{}
+			inHead = tag != "body"
+		case nhtml.AttributeToken:
+			attrs[string(l.Text())] = html.UnescapeString(strings.Trim(string(l.AttrVal()), `"'`))
+		case nhtml.StartTagCloseToken, nhtml.StartTagVoidToken:
+			switch tag {
+			case "title":
+				inTitle = true
+			case "meta":
+				content := strings.TrimSpace(attrs["content"])
+
+				switch strings.ToLower(lookupAttr(attrs, "property", "name")) {
+				case "og:title":
+					ogTitle = content
+				case "description":
+					desc = content
+				case "og:description":
+					ogDesc = content
+				case "og:image", "og:image:url", "twitter:image":
+					if m.Image == "" {
+						m.Image = resolveURL(base, content)
+					}
+				}
+			case "link":
+				if m.Favicon == "" && isIconRel(attrs["rel"]) {
+					m.Favicon = resolveURL(base, attrs["href"])
+				}
+			}
+		case nhtml.TextToken:
+			if inTitle && title == "" {
+				title = strings.Join(strings.Fields(html.UnescapeString(string(l.Text()))), " ")
+			}
+		case nhtml.EndTagToken:
+			inTitle = false
+			inHead = !strings.EqualFold(string(l.Text()), "head")
+		}
+	}
+
+	m.Title, _ = lo.Coalesce(ogTitle, title)
+	m.Description, _ = lo.Coalesce(ogDesc, desc)
+
+	return m
+}
+
+// lookupAttr returns the value of the first of the attributes that is set.
+func lookupAttr(attrs map[string]string, names ...string) string {
+	for _, name := range names {
+		if v, ok := attrs[name]; ok {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// isIconRel reports whether the rel attribute of a link describes a favicon,
+// e.g. "icon" or "shortcut icon".
+func isIconRel(rel string) bool {
+	return lo.Contains(strings.Fields(strings.ToLower(rel)), "icon")
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+
+	return u.String()
+}
@@ -0,0 +1,184 @@
+package goldmark_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark/renderer"
+)
+
+const exampleDomain = `<!doctype html>
+<html>
+<head>
+    <title>Example Domain</title>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <meta name="description" content="This domain is for use in illustrative examples in documents. You may use this domain in literature without prior coordination or asking for permission." />
+</head>
+<body>
+<div>
+    <h1>Example Domain</h1>
+    <p>This domain is for use in illustrative examples in documents.</p>
+</div>
+</body>
+</html>`
+
+func TestHTMLMetadataProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		mu    sync.Mutex
+		count int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/og":
+			_, _ = io.WriteString(w, `<html><head>
+<title>Fallback</title>
+<meta name="description" content="Fallback description">
+<meta property="og:title" content="Open &amp; Graph">
+<meta property="og:description" content="A description.">
+<meta property="og:image" content="/images/card.png">
+<link rel="shortcut icon" href="favicon.ico">
+</head><body><meta property="og:title" content="Body"></body></html>`)
+		case "/plain":
+			_, _ = io.WriteString(w, `<HTML><HEAD><TITLE>  Plain
+  Title </TITLE><META NAME="Description" CONTENT="Plain description"></HEAD></HTML>`)
+		case "/redirect":
+			http.Redirect(w, r, "/sub/page", http.StatusFound)
+		case "/sub/page":
+			_, _ = io.WriteString(w, `<title>Redirected</title><link rel="icon" href="icon.svg">`)
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = io.WriteString(w, "<title>Not a page</title>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewHTMLMetadataProvider(srv.Client())
+
+	m, err := p.Metadata(ctx, srv.URL+"/og")
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{
+		Title:       "Open & Graph",
+		Description: "A description.",
+		Favicon:     srv.URL + "/favicon.ico",
+		Image:       srv.URL + "/images/card.png",
+	}, m)
+
+	m, err = p.Metadata(ctx, srv.URL+"/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{Title: "Plain Title", Description: "Plain description"}, m)
+
+	// relative URLs are resolved against the final URL
+	m, err = p.Metadata(ctx, srv.URL+"/redirect")
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{Title: "Redirected", Favicon: srv.URL + "/sub/icon.svg"}, m)
+
+	m, err = p.Metadata(ctx, srv.URL+"/image.png")
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{}, m)
+
+	_, err = p.Metadata(ctx, srv.URL+"/missing")
+	assert.EqualError(t, err, "unexpected status 404 Not Found")
+
+	// each page is only fetched once
+	_, err = p.Metadata(ctx, srv.URL+"/og")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+}
+
+func TestBookmarks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	blocks, err := cli.GetAllBlocks(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	// the bookmark and the link preview
+	g := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) { return cli.GetNotionPage(ctx, id) },
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{blocks[39], blocks[52]}, nil
+		},
+	}
+
+	// example.com is served locally
+	metadata := NewHTMLMetadataProvider(&http.Client{
+		Transport: &testRoundtripper{roundTrip: func(r *http.Request) (*http.Response, error) {
+			if r.URL.String() != "https://example.com/" {
+				return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: http.NoBody}, nil
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+				Body:       io.NopCloser(strings.NewReader(exampleDomain)),
+				Request:    r,
+			}, nil
+		}},
+	})
+
+	render := func(t *testing.T, rd renderer.Renderer, opts ...Option) string {
+		t.Helper()
+
+		doc, source, err := NewConverter(g, opts...).Page(ctx, fake.PageID)
+		assert.NoError(t, err)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, rd.Render(w, source, doc))
+
+		return w.String()
+	}
+
+	t.Run("html", func(t *testing.T) {
+		t.Parallel()
+
+		html, err := fake.HTMLExport.ReadFile("html/" + pageRoot("Example Page", fake.PageID) + ".html")
+		assert.NoError(t, err)
+
+		got := render(t, r, WithMetadataProvider(metadata))
+
+		for _, id := range []string{"f435a9bc-ec00-44de-82b9-d1ce933ee623", "f3b6a5e8-7478-46ba-8602-64ee17e3b998"} {
+			figure := regexp.MustCompile(`<figure id="` + id + `".*?</figure>`)
+			assert.Equal(t, string(figure.Find(html)), figure.FindString(got))
+		}
+
+		// without metadata, the URL is the title
+		assert.Contains(t, render(t, r),
+			`<div class="bookmark-title">https://example.com/</div>`)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "[Example Domain](https://example.com/)\n\nA bookmark object.\n\n"+
+			"[https://github.com/faetools/go-notion](https://github.com/faetools/go-notion)\n",
+			render(t, markdown.New(), WithMetadataProvider(metadata)))
+	})
+}
@@ -2,68 +2,179 @@ package goldmark
 
 import (
 	"context"
-	"fmt"
 	"net/url"
+	"path"
 	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/faetools/go-notion/pkg/notion"
 	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/samber/lo"
 	"github.com/yuin/goldmark/ast"
 	extast "github.com/yuin/goldmark/extension/ast"
 	"github.com/yuin/goldmark/util"
 )
 
+// pageCollector collects the nodes of a single page, block or database.
 type pageCollector struct {
-	root string
+	*Converter
 
-	ctx context.Context
-	cli notion.Getter
+	root string
+	ctx  context.Context
+	// cancel cancels ctx once the conversion failed
+	cancel context.CancelFunc
+
+	// pageID is the ID of the converted page, if any
+	pageID notion.UUID
+
+	// the blocks are converted concurrently
+	mu        sync.Mutex
+	locations map[notion.UUID]*location
+	headings  map[notion.UUID]notion.RichTexts
+	source    []byte
+	assetErrs AssetErrors
+	// err is the first error that stopped the conversion
+	err error
 }
 
 type blockCollector struct {
 	p *pageCollector
 
+	// parent is the page or block the blocks belong to
+	parent notion.Id
+
+	// synced are the source IDs of the synced blocks the blocks are in
+	synced []notion.UUID
+
+	// depth is the number of ancestors the blocks have
+	depth int
+
 	list     *ast.List
 	listType notion.BlockType
 	res      []ast.Node
 }
 
 // GetPage returns the goldmark nodes of a notion page.
-func GetPage(ctx context.Context, cli notion.Getter, id notion.Id, max int) ([]ast.Node, error) {
-	p, err := cli.GetNotionPage(ctx, id)
-	if err != nil {
+// It is a shorthand for creating a Converter with max top-level blocks.
+//
+// Since the source is not returned, the content of code blocks and, with WithSource,
+// all other text is missing; use Converter.Page to render the whole page.
+//
+// If only some assets could not be stored, the nodes are returned
+// together with an AssetErrors error.
+func GetPage(ctx context.Context, cli notion.Getter, id notion.Id, max int, opts ...Option) ([]ast.Node, error) {
+	doc, _, err := NewConverter(cli, append(opts, WithMaxBlocks(max))...).Page(ctx, id)
+	if doc == nil {
 		return nil, err
 	}
 
-	c := &pageCollector{
-		root: getDir(p.Title(), p.Id),
-		ctx:  ctx, cli: cli,
+	nodes := []ast.Node{}
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		nodes = append(nodes, n)
+	}
+
+	return nodes, err
+}
+
+// fail stops the conversion because of err and cancels all other requests.
+func (p *pageCollector) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+
+	p.cancel()
+}
+
+// failure returns the error that stopped the conversion, or err if it was not stopped by one.
+func (p *pageCollector) failure(err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	return err
+}
+
+// result returns the document of the nodes and its source
+// together with the errors that did not stop the conversion.
+func (p *pageCollector) result(nodes []ast.Node) (*ast.Document, []byte, error) {
+	doc := ast.NewDocument()
+	for _, n := range nodes {
+		doc.AppendChild(doc, n)
+	}
+
+	if p.textSource {
+		p.backStrings(doc)
+	}
+
+	if len(p.assetErrs) > 0 {
+		return doc, p.source, p.assetErrs
+	}
+
+	return doc, p.source, nil
+}
+
+// asset returns the destination of a file hosted by Notion that belongs in dir.
+// If there is no asset store or the file could not be stored, it is the signed URL.
+func (p *pageCollector) asset(dir, rawURL string) []byte {
+	if p.assets == nil {
+		return util.URLEscape([]byte(rawURL), true)
+	}
+
+	if d, err := url.PathUnescape(dir); err == nil {
+		dir = d
 	}
 
-	return c.getBlocks(id, max)
+	dest, err := p.assets.Store(p.ctx, dir, rawURL)
+	if err != nil {
+		p.mu.Lock()
+		p.assetErrs = append(p.assetErrs, &AssetError{URL: rawURL, Err: err})
+		p.mu.Unlock()
+
+		return util.URLEscape([]byte(rawURL), true)
+	}
+
+	return util.URLEscape([]byte(filepath.ToSlash(dest)), true)
+}
+
+// getBlocks returns the goldmark nodes of the children of a block or page.
+func (c *pageCollector) getBlocks(id notion.Id, max, depth int) ([]ast.Node, error) {
+	return (&blockCollector{p: c, parent: id, depth: depth}).getBlocks(max)
 }
 
-// getBlocks returns the goldmark nodes of a notion page.
-func (c *pageCollector) getBlocks(id notion.Id, max int) ([]ast.Node, error) {
-	blocks, err := c.cli.GetAllBlocks(c.ctx, id)
+// children returns a collector for the children of a block.
+func (c *blockCollector) children(id notion.Id, depth int) *blockCollector {
+	return &blockCollector{p: c.p, parent: id, depth: depth, synced: c.synced}
+}
+
+// getBlocks returns the goldmark nodes of the children of the parent.
+func (c *blockCollector) getBlocks(max int) ([]ast.Node, error) {
+	blocks, err := c.p.cli.GetAllBlocks(c.p.ctx, c.parent)
+	switch {
+	case c.p.ctx.Err() != nil:
+		return nil, c.p.ctx.Err()
+	case err != nil:
+		return nil, FetchErrors{{ID: notion.UUID(c.parent), Err: err}}
+	}
+
+	if max >= 0 && len(blocks) > max {
+		blocks = blocks[:max]
+	}
+
+	nodes, err := c.toNodesWithChildren(blocks)
 	if err != nil {
 		return nil, err
 	}
 
-	bc := &blockCollector{p: c}
-
 	for i, b := range blocks {
-		if i == max {
-			break
-		}
-
-		if err := bc.collectBlock(b); err != nil {
-			return nil, err
-		}
+		c.collectBlock(b, nodes[i])
 	}
 
-	return bc.collectBlocks(c.ctx, id)
+	return c.collectBlocks(c.p.ctx, c.parent)
 }
 
 func (c *blockCollector) collectBlocks(ctx context.Context, id notion.Id) ([]ast.Node, error) {
@@ -115,10 +226,9 @@ func newList(tp notion.BlockType) *ast.List {
 
 type listType int
 
-func (c *blockCollector) collectBlock(b notion.Block) error {
-	n, err := c.toNodeWithChildren(b)
-	if err != nil {
-		return err
+func (c *blockCollector) collectBlock(b notion.Block, n ast.Node) {
+	if n == nil {
+		return
 	}
 
 	switch b.Type {
@@ -135,29 +245,91 @@ func (c *blockCollector) collectBlock(b notion.Block) error {
 
 		c.res = append(c.res, n)
 	}
-
-	return nil
 }
 
+// toNodeWithChildren returns the node of the block with all its children.
+// It returns nil if the block is unsupported and should be skipped.
 func (c *blockCollector) toNodeWithChildren(b notion.Block) (ast.Node, error) {
 	n := c.toNode(b)
+	if err := c.p.resolveMentions(n); err != nil {
+		return nil, err
+	}
+
+	n, err := c.p.checkUnsupported(b.Id, n)
+	if err != nil || n == nil {
+		return nil, err
+	}
 
 	switch b.Type {
 	case notion.BlockTypeChildPage:
+		return n, nil
+	case notion.BlockTypeLinkToPage:
+		if n.Kind() == n_ast.KindUnsupported {
+			return n, nil
+		}
+
+		link, err := c.p.linkTo(*b.LinkToPage)
+		if err != nil {
+			return nil, err
+		}
+
+		n.AppendChild(n, link)
+
 		return n, nil
 	case notion.BlockTypeChildDatabase:
-		table, err := c.p.getTable(notion.Id(b.Id))
+		db, err := c.p.getDatabase(notion.Id(b.Id))
 		if err != nil {
 			return nil, err
 		}
 
-		n.AppendChild(n, table)
+		if err := c.p.resolveMentions(db); err != nil {
+			return nil, err
+		}
+
+		n.AppendChild(n, db)
+
+		return c.p.checkUnsupported(b.Id, n)
+	case notion.BlockTypeTable:
+		rows, err := c.p.cli.GetAllBlocks(c.p.ctx, notion.Id(b.Id))
+		if err != nil {
+			return nil, FetchErrors{{ID: b.Id, Err: err}}
+		}
+
+		appendTableRows(n, b.Table, rows)
+
+		return c.p.checkUnsupported(b.Id, n)
+	case notion.BlockTypeColumnList, notion.BlockTypeColumn:
+		// the columns and their content are appended directly
+		// and do not count as nested blocks
+		children, err := c.children(notion.Id(b.Id), c.depth).getBlocks(-1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+			n.AppendChild(n, child)
+		}
 
 		return n, nil
 	}
 
-	if !b.HasChildren {
-		return c.toNode(b), nil
+	// the children of unsupported blocks are not converted
+	if !b.HasChildren || n.Kind() == n_ast.KindUnsupported ||
+		(c.p.maxDepth >= 0 && c.depth >= c.p.maxDepth) {
+		return n, nil
+	}
+
+	children := c.children(notion.Id(b.Id), c.depth+1)
+
+	if s, ok := n.(*n_ast.SyncedBlock); ok {
+		// guard against synced blocks that contain themselves
+		if lo.Contains(c.synced, s.SourceID) {
+			return n, nil
+		}
+
+		// the content of copies is in the original synced block
+		children = c.children(notion.Id(s.SourceID), c.depth+1)
+		children.synced = append(c.synced[:len(c.synced):len(c.synced)], s.SourceID)
 	}
 
 	bc := &n_ast.BlockChildren{}
@@ -171,12 +343,12 @@ func (c *blockCollector) toNodeWithChildren(b notion.Block) (ast.Node, error) {
 		n.AppendChild(n, bc)
 	}
 
-	children, err := c.p.getBlocks(notion.Id(b.Id), -1)
+	nodes, err := children.getBlocks(-1)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, child := range children {
+	for _, child := range nodes {
 		bc.AppendChild(bc, child)
 	}
 
@@ -188,17 +360,17 @@ func (c *blockCollector) toNode(b notion.Block) ast.Node {
 	case notion.BlockTypeParagraph:
 		return toNodeParagraph(ast.NewParagraph(), b.Id, b.Paragraph)
 	case notion.BlockTypeHeading1:
-		return toNodeParagraph(ast.NewHeading(1), b.Id, b.Heading1)
+		return c.p.toNodeHeading(1, b.Id, b.Heading1)
 	case notion.BlockTypeHeading2:
-		return toNodeParagraph(ast.NewHeading(2), b.Id, b.Heading2)
+		return c.p.toNodeHeading(2, b.Id, b.Heading2)
 	case notion.BlockTypeHeading3:
-		return toNodeParagraph(ast.NewHeading(3), b.Id, b.Heading3)
+		return c.p.toNodeHeading(3, b.Id, b.Heading3)
 	case notion.BlockTypeCallout:
-		return toNodeCallout(b.Id, b.Callout)
+		return c.p.toNodeCallout(b.Id, b.Callout)
 	case notion.BlockTypeQuote:
 		return toNodeParagraph(ast.NewBlockquote(), b.Id, b.Quote)
 	case notion.BlockTypeSyncedBlock:
-		return n_ast.NewSyncedBlock()
+		return n_ast.NewSyncedBlock(b.Id, *b.SyncedBlock)
 	case notion.BlockTypeToDo:
 		return toNodeToDo(b.Id, b.ToDo)
 	case notion.BlockTypeNumberedListItem:
@@ -208,49 +380,62 @@ func (c *blockCollector) toNode(b notion.Block) ast.Node {
 	case notion.BlockTypeToggle:
 		return toNodeToggle(b.Id, b.Toggle)
 	case notion.BlockTypeCode:
-		return toNodeCode(b.Id, b.Code)
+		return c.p.toNodeCode(b.Id, b.Code)
 	case notion.BlockTypeChildPage:
-		return c.p.toNodeChildPage(b.Id, b.ChildPage)
+		return c.toNodeChildPage(b.Id, b.ChildPage)
 	case notion.BlockTypeChildDatabase:
-		return toNodeChildDatabase(b.Id, b.ChildDatabase)
+		return c.p.toNodeChildDatabase(b.Id, b.ChildDatabase)
 	case notion.BlockTypeEmbed:
 		return c.p.toNodeEmbed(b.Id, b.Embed.Url, &b.Embed.Caption)
 	case notion.BlockTypePdf:
-		return c.p.toNodeEmbed(b.Id, b.Pdf.URL(), b.Pdf.Caption)
-
-	// 	// TODO validate:
-	// case notion.BlockTypeBookmark:
-	// 	return toNodeBookmark(b.Bookmark)
-
-	// case notion.BlockTypeTable:
-	// 	// NOTE: toNode should never be called with notion.BlockTypeTableRow
-	// 	// the below function will call the appropriate methods
-	// 	return toNodeTable(b.Table)
-	// case notion.BlockTypeDivider:
-	// 	return ast.NewThematicBreak()
-
-	// case notion.BlockTypeEquation:
-	// 	return toNodeEquation(b.Equation)
-	// case notion.BlockTypeFile:
-	// 	return n_ast.NewFile(*b.File, n_ast.FileTypeGeneric)
-
-	// case notion.BlockTypeImage:
-	// 	return n_ast.NewFile(*b.File, n_ast.FileTypeImage)
-	// case notion.BlockTypeLinkPreview:
-	// 	return toNodeLinkPreview(b.LinkPreview)
-	// case notion.BlockTypeLinkToPage:
-	// 	return n_ast.NewLinkToPage(*b.LinkToPage)
-
-	// case notion.BlockTypeTableOfContents:
-	// 	return toNodeTableOfContents(b.TableOfContents)
-
-	// case notion.BlockTypeVideo:
-	// 	return toNodeVideo(b.Video)
+		return c.p.toNodeFile(b.Id, *b.Pdf, n_ast.FileTypePDF)
+	case notion.BlockTypeImage:
+		return c.p.toNodeFile(b.Id, *b.Image, n_ast.FileTypeImage)
+	case notion.BlockTypeVideo:
+		return c.p.toNodeFile(b.Id, notion.FileWithCaption{
+			External: b.Video.External,
+			File:     b.Video.File,
+			Type:     notion.FileWithCaptionType(b.Video.Type),
+			Caption:  &b.Video.Caption,
+		}, n_ast.FileTypeVideo)
+	case notion.BlockTypeAudio:
+		return c.p.toNodeFile(b.Id, *b.Audio, n_ast.FileTypeAudio)
+	case notion.BlockTypeFile:
+		return c.p.toNodeFile(b.Id, *b.File, n_ast.FileTypeGeneric)
+	case notion.BlockTypeTable:
+		// NOTE: toNode should never be called with notion.BlockTypeTableRow
+		// the rows are appended in toNodeWithChildren
+		return toNodeTable(b.Id)
+	case notion.BlockTypeColumnList:
+		return toNodeColumnList(b.Id)
+	case notion.BlockTypeColumn:
+		return toNodeColumn(b.Id)
+	case notion.BlockTypeLinkToPage:
+		// NOTE: the link is appended in toNodeWithChildren
+		return toNodeLinkToPage(b.Id, b.LinkToPage)
+	case notion.BlockTypeTableOfContents:
+		// NOTE: the headings are added once all blocks are converted
+		return toNodeTableOfContents(b.Id, b.TableOfContents)
+	case notion.BlockTypeBookmark:
+		return c.p.toNodeBookmark(b.Id, b.Bookmark)
+	case notion.BlockTypeLinkPreview:
+		return toNodeLinkPreview(b.Id, b.LinkPreview)
+	case notion.BlockTypeEquation:
+		return toNodeEquationBlock(b.Id, b.Equation)
+	case notion.BlockTypeDivider:
+		n := ast.NewThematicBreak()
+		n.SetAttributeString(attrID, []byte(b.Id))
+
+		return n
+
 	default: // includes
 		// notion.BlockTypeUnsupported (which we'll never support by its nature)
-		// notion.BlockTypeColumn, notion.BlockTypeColumnList (which we plan to support in the future)
 		// notion.BlockTypeTemplate (we're unsure when this is returned)
-		panic(fmt.Sprintf("unknown node notion block type %q", b.Type))
+		// and any block type notion adds in the future
+		n := n_ast.NewUnsupported(true, string(b.Type))
+		n.SetAttributeString(attrID, []byte(b.Id))
+
+		return n
 	}
 }
 
@@ -262,14 +447,19 @@ func toNodeParagraph(n ast.Node, id notion.UUID, p *notion.Paragraph) ast.Node {
 	return n
 }
 
-func toNodeCallout(id notion.UUID, callout *notion.Callout) ast.Node {
+func (p *pageCollector) toNodeCallout(id notion.UUID, callout *notion.Callout) ast.Node {
 	n := &n_ast.Callout{}
 
 	setClasses(n, callout.Color, []byte("callout"))
 	n.SetAttributeString("style", []byte("white-space:pre-wrap;display:flex"))
 	n.SetAttributeString("id", []byte(id))
 
-	n.AppendChild(n, n_ast.NewIcon(callout.Icon))
+	icon := n_ast.NewIcon(callout.Icon)
+	n.AppendChild(n, icon)
+
+	if img, ok := icon.FirstChild().(*ast.Image); ok && callout.Icon.Type == notion.IconTypeFile {
+		img.Destination = p.asset(p.root, callout.Icon.URL())
+	}
 
 	text := &n_ast.CalloutText{}
 	n.AppendChild(n, text)
@@ -349,19 +539,24 @@ func toNodeToggle(id notion.UUID, t *notion.Paragraph) ast.Node {
 	return n
 }
 
-func toNodeCode(id notion.UUID, c *notion.Code) ast.Node {
-	lang := ast.NewText() // TODO use source to create language
+func (p *pageCollector) toNodeCode(id notion.UUID, c *notion.Code) ast.Node {
+	var info *ast.Text
+	if lang := ChromaLexer(c.Language); lang != "" {
+		info = ast.NewTextSegment(p.segment(lang))
+	}
 
-	n := ast.NewFencedCodeBlock(lang)
+	n := ast.NewFencedCodeBlock(info)
 
 	n.SetAttributeString(attrID, []byte(id))
 	setClasses(n, "", classCode)
 
-	if c.Language != "" {
-		n.SetAttributeString("language", []byte(c.Language))
+	// code is plain text, so its annotations and links are left out
+	code := ""
+	for _, t := range c.RichText {
+		code += t.PlainText
 	}
 
-	appendRichTexts(n, c.RichText)
+	n.SetLines(p.lines(code))
 
 	if c.Caption != nil && len(*c.Caption) > 0 {
 		cap := &n_ast.Caption{}
@@ -372,36 +567,141 @@ func toNodeCode(id notion.UUID, c *notion.Code) ast.Node {
 	return n
 }
 
-func (c *pageCollector) toNodeChildPage(id notion.UUID, child *notion.Child) ast.Node {
+func (c *blockCollector) toNodeChildPage(id notion.UUID, child *notion.Child) ast.Node {
 	n := n_ast.NewChildPage(*child)
 
 	n.SetAttributeString(attrID, []byte(id))
 	setClasses(n, "", classLinkToPage)
 
-	n.AppendChild(n, linkToPage(child.Title, id, c.root))
+	parentID := notion.UUID(c.parent)
+
+	parent := &notion.Parent{Type: notion.ParentTypeBlockId, BlockId: &parentID}
+	if parentID == c.p.pageID {
+		parent = &notion.Parent{Type: notion.ParentTypePageId, PageId: &parentID}
+	}
+
+	n.AppendChild(n, c.p.linkToPage(PageLink{ID: id, Title: child.Title, Parent: parent, Dir: c.p.root}))
+
+	return n
+}
+
+func toNodeLinkToPage(id notion.UUID, l *notion.LinkToPage) ast.Node {
+	switch l.Type {
+	case notion.LinkToPageTypePageId, notion.LinkToPageTypeDatabaseId:
+	default:
+		u := n_ast.NewUnsupported(true, string(l.Type))
+		u.SetAttributeString(attrID, []byte(id))
+
+		return u
+	}
+
+	n := n_ast.NewLinkToPage(*l)
+
+	// like notion, use the ID of the linked page
+	n.SetAttributeString(attrID, []byte(l.ID()))
+	setClasses(n, "", classLinkToPage)
 
 	return n
 }
 
-func linkToPage(title string, id notion.UUID, dir ...string) *ast.Link {
+// linkTo returns the link of a link_to_page block, which includes the icon of the page.
+func (p *pageCollector) linkTo(l notion.LinkToPage) (*ast.Link, error) {
+	id := l.ID()
+
+	locate := p.locatePage
+	if l.Type == notion.LinkToPageTypeDatabaseId {
+		locate = p.locateDatabase
+	}
+
+	loc, err := locate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	link := p.linkToPage(loc.pageLink(id))
+
+	if loc.icon != nil {
+		icon := n_ast.NewIcon(*loc.icon)
+
+		if img, ok := icon.FirstChild().(*ast.Image); ok && loc.icon.Type == notion.IconTypeFile {
+			img.Destination = p.asset(p.root, loc.icon.URL())
+		}
+
+		link.InsertBefore(link, link.FirstChild(), icon)
+	}
+
+	return link, nil
+}
+
+// linkToPage returns a link to the page with its title as text.
+// The directory of the link is URL-escaped.
+func (p *pageCollector) linkToPage(l PageLink) *ast.Link {
 	n := ast.NewLink()
 
-	if title == "" {
-		title = "Untitled"
+	if l.Title == "" {
+		l.Title = "Untitled"
 	}
 
-	fileName := fmt.Sprintf("%s %s.html", title,
-		strings.ReplaceAll(string(id), "-", ""))
+	if d, err := url.PathUnescape(l.Dir); err == nil {
+		l.Dir = d
+	}
 
-	n.Destination = util.URLEscape([]byte(filepath.Join(append(dir, fileName)...)), true)
+	dest := p.links.ResolveLink(l)
+	n.Destination = util.URLEscape([]byte(dest), true)
 
-	n.AppendChild(n, newString(title))
+	n.AppendChild(n, newString(l.Title))
 
 	return n
 }
 
-func toNodeChildDatabase(id notion.UUID, db *notion.Child) ast.Node {
-	n := &n_ast.ChildDatabase{}
+// resolveMentions links the mentions of pages and databases within n.
+func (p *pageCollector) resolveMentions(n ast.Node) error {
+	return ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		m, ok := n.(*n_ast.Mention)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var (
+			loc *location
+			id  notion.UUID
+			err error
+		)
+
+		switch m.Content.Type {
+		case notion.MentionTypePage:
+			id = m.Content.Page.Id
+			loc, err = p.locatePage(id)
+		case notion.MentionTypeDatabase:
+			id = m.Content.Database.Id
+			loc, err = p.locateDatabase(id)
+		default:
+			return ast.WalkSkipChildren, nil
+		}
+
+		if err != nil {
+			return ast.WalkStop, err
+		}
+
+		// the mention keeps its text, which is how it is displayed in notion
+		link := p.linkToPage(loc.pageLink(id))
+		link.RemoveChildren(link)
+
+		for c := m.FirstChild(); c != nil; c = m.FirstChild() {
+			link.AppendChild(link, c)
+		}
+
+		m.AppendChild(m, link)
+
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+func (c *pageCollector) toNodeChildDatabase(id notion.UUID, db *notion.Child) ast.Node {
+	n := &n_ast.ChildDatabase{
+		Title: db.Title,
+		Path:  path.Join(c.root, getDir(db.Title, id)),
+	}
 	n.SetAttributeString(attrID, []byte(id))
 	setClasses(n, "", classCollectionContent)
 
@@ -414,6 +714,133 @@ func toNodeChildDatabase(id notion.UUID, db *notion.Child) ast.Node {
 	return n
 }
 
+func toNodeTable(id notion.UUID) ast.Node {
+	n := extast.NewTable()
+	n.SetAttributeString(attrID, []byte(id))
+	setClasses(n, "", classSimpleTable)
+
+	return n
+}
+
+func toNodeColumnList(id notion.UUID) ast.Node {
+	n := n_ast.NewColumnList()
+	n.SetAttributeString(attrID, []byte(id))
+	setClasses(n, "", classColumnList)
+
+	return n
+}
+
+func toNodeColumn(id notion.UUID) ast.Node {
+	n := n_ast.NewColumn()
+	n.SetAttributeString(attrID, []byte(id))
+	setClasses(n, "", classColumn)
+
+	return n
+}
+
+// appendTableRows appends the table rows to the table.
+// If the table has a column header, the first row becomes the table header.
+// If the table has a row header, the first cell of each row is marked with scope="row".
+func appendTableRows(n ast.Node, table *notion.Table, rows notion.Blocks) {
+	for i, b := range rows {
+		if b.Type != notion.BlockTypeTableRow {
+			continue
+		}
+
+		row := extast.NewTableRow(nil)
+		isHeader := i == 0 && table.HasColumnHeader
+
+		for j, content := range b.TableRow.Cells {
+			cell := extast.NewTableCell()
+			appendRichTexts(cell, content)
+
+			switch {
+			case isHeader:
+				setClasses(cell, "", classSimpleTableHeaderCell)
+			case j == 0 && table.HasRowHeader:
+				setClasses(cell, "", classSimpleTableHeaderCell)
+				cell.SetAttributeString(attrScope, scopeRow)
+			default:
+				setClasses(cell, "")
+			}
+
+			row.AppendChild(row, cell)
+		}
+
+		if !isHeader {
+			row.SetAttributeString(attrID, []byte(b.Id))
+			n.AppendChild(n, row)
+
+			continue
+		}
+
+		header := extast.NewTableHeader(row)
+		header.SetAttributeString(attrID, []byte(b.Id))
+		setClasses(header, "", classSimpleTableHeader)
+		n.AppendChild(n, header)
+	}
+}
+
+func (p *pageCollector) toNodeFile(id notion.UUID, f notion.FileWithCaption, tp n_ast.FileType) ast.Node {
+	switch f.Type {
+	case notion.FileWithCaptionTypeExternal, notion.FileWithCaptionTypeFile:
+	default:
+		u := n_ast.NewUnsupported(true, string(f.Type))
+		u.SetAttributeString(attrID, []byte(id))
+
+		return u
+	}
+
+	n := n_ast.NewFile(f, tp)
+	n.SetAttributeString(attrID, []byte(id))
+
+	if !n.External {
+		n.SetDestination(p.asset(p.root, f.URL()))
+	}
+
+	if tp == n_ast.FileTypeImage {
+		setClasses(n, "", classImage)
+	}
+
+	addCaption(n, f.Caption)
+
+	return n
+}
+
+func (p *pageCollector) toNodeBookmark(id notion.UUID, b *notion.Bookmark) ast.Node {
+	n := &n_ast.Bookmark{URL: b.Url}
+	n.SetAttributeString(attrID, []byte(id))
+
+	if p.metadata != nil {
+		// like Notion, only show the URL if the metadata is not available
+		if m, err := p.metadata.Metadata(p.ctx, b.Url); err == nil && m != nil {
+			n.Title = m.Title
+			n.Description = m.Description
+			n.Favicon = m.Favicon
+			n.Image = m.Image
+		}
+	}
+
+	addCaption(n, &b.Caption)
+
+	return n
+}
+
+func toNodeEquationBlock(id notion.UUID, eq *notion.Equation) ast.Node {
+	n := &n_ast.EquationBlock{Expression: eq.Expression}
+	n.SetAttributeString(attrID, []byte(id))
+	setClasses(n, "", classEquation)
+
+	return n
+}
+
+func toNodeLinkPreview(id notion.UUID, pr *notion.LinkPreview) ast.Node {
+	n := &n_ast.LinkPreview{URL: pr.Url}
+	n.SetAttributeString(attrID, []byte(id))
+
+	return n
+}
+
 func (p *pageCollector) toNodeEmbed(id notion.UUID, rawURL string, caption *notion.RichTexts) ast.Node {
 	n := &n_ast.Embed{}
 	n.SetAttributeString(attrID, []byte(id))
@@ -424,16 +851,17 @@ func (p *pageCollector) toNodeEmbed(id notion.UUID, rawURL string, caption *noti
 	link := ast.NewLink()
 	src.AppendChild(src, link)
 
-	u, _ := url.Parse(rawURL) // TODO don't underscore error
-
-	fileName := filepath.Base(u.Path)
-
-	if u.Host == "s3.us-west-2.amazonaws.com" &&
-		strings.HasPrefix(u.Path, "/secure.notion-static.com/") {
-		// TODO download
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		u = &url.URL{Path: rawURL}
+	}
 
-		link.Destination = []byte(filepath.Join(p.root, fileName))
+	if isNotionHosted(u) {
+		link.Destination = p.asset(p.root, rawURL)
 		u.RawQuery = ""
+
+		// HACK: notion displays the legacy host name in its exports
+		u.Host = "s3-us-west-2.amazonaws.com"
 	} else {
 		link.Destination = []byte(rawURL)
 	}
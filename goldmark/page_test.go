@@ -3,676 +3,451 @@ package goldmark_test
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
-	"path/filepath"
-	"strconv"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
-	extast "github.com/yuin/goldmark/extension/ast"
-
-	n_ast "github.com/faetools/notion-to-goldmark/ast"
-
 	"github.com/faetools/go-notion/pkg/fake"
 	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
 	. "github.com/faetools/notion-to-goldmark/goldmark"
+	notionhtml "github.com/faetools/notion-to-goldmark/renderer/html"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/tdewolff/parse/v2"
+	nhtml "github.com/tdewolff/parse/v2/html"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
-	"github.com/yuin/goldmark/extension"
-	"github.com/yuin/goldmark/renderer"
-	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/util"
 )
 
-const max = 34
-
-func (r *htmlRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
-	reg.Register(n_ast.KindColor, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			w.WriteString("</mark>")
-			return ast.WalkContinue, nil
-		}
-
-		w.WriteString(`<mark class="highlight-`)
-		w.Write([]byte(n.(*n_ast.Color).Color))
-		w.WriteString(`">`)
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(ast.KindCodeSpan, renderTag("code", html.CodeAttributeFilter))
-
-	reg.Register(n_ast.KindUnderline, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if entering {
-			_, _ = w.WriteString(`<span style="border-bottom:0.05em solid">`)
-			return ast.WalkContinue, nil
-		}
-
-		_, _ = w.WriteString("</span>")
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(ast.KindParagraph, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString("</p>")
-			return ast.WalkContinue, nil
-		}
-
-		_, _ = w.WriteString("<p")
-		html.RenderAttributes(w, n, html.ParagraphAttributeFilter)
-		_ = w.WriteByte('>')
+// linkedDatabaseID is the ID of the database the linked database block of the example page shows.
+// The fake client does not know it, so the child database of the example page takes its place.
+const linkedDatabaseID = "d105edb4-586a-4dcc-aaa6-ea944eb8d864"
 
-		if !n.HasChildren() {
-			// for some reason, notion adds a new line here
-			_ = w.WriteByte('\n')
-		}
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(n_ast.KindBlockChildren, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		switch n.Parent().Kind() {
-		case ast.KindParagraph: // ok
-		case n_ast.KindCalloutText, n_ast.KindSyncedBlock,
-			ast.KindListItem, n_ast.KindToggle, n_ast.KindChildPage:
-			// NOTE: maybe we change the implementation so as to not use KindBlockChildren
-			// and instead call it "indented" or something
-			return ast.WalkContinue, nil
-		default:
-			fmt.Printf("unknown block children parent: %v\n", n.Parent().Kind())
-		}
-
-		if entering {
-			_, _ = w.WriteString(`<div class="indented">`)
-		} else {
-			_, _ = w.WriteString(`</div>`)
-		}
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(ast.KindHeading, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		n := node.(*ast.Heading)
-		if entering {
-			_, _ = w.WriteString("<h")
-			_ = w.WriteByte("0123456"[n.Level])
-			html.RenderAttributes(w, node, html.HeadingAttributeFilter)
-		} else {
-			_, _ = w.WriteString("</h")
-			_ = w.WriteByte("0123456"[n.Level])
-		}
-
-		_ = w.WriteByte('>')
-		return ast.WalkContinue, nil
-	})
-
-	renderFigure := renderTag("figure", html.GlobalAttributeFilter)
-
-	reg.Register(n_ast.KindCallout, renderFigure)
-
-	reg.Register(n_ast.KindIcon, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString(`</div>`)
-			return ast.WalkContinue, nil
-		}
-
-		_, _ = w.WriteString(`<div style="font-size:1.5em">`)
-
-		if emoji := n.(*n_ast.Icon).Emoji; emoji != "" {
-			_, _ = w.WriteString(`<span class="icon">`)
-			_, _ = w.WriteString(emoji)
-			_, _ = w.WriteString(`</span>`)
-		}
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(n_ast.KindCalloutText, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if entering {
-			_, _ = w.WriteString(`<div style="width:100%">`)
-		} else {
-			_, _ = w.WriteString(`</div>`)
-		}
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(ast.KindImage, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			return ast.WalkContinue, nil
-		}
-
-		n := node.(*ast.Image)
-
-		_, _ = w.WriteString("<img")
-		html.RenderAttributes(w, n, html.ImageAttributeFilter)
-
-		_, _ = w.WriteString(` src="`)
-		_, _ = w.Write(util.EscapeHTML(util.URLEscape(n.Destination, true)))
-		_ = w.WriteByte('"')
-
-		// TODO remove in favor of below code
-		html.RenderAttributes(w, n, util.NewBytesFilter([]byte("alt")))
-
-		if alt := n.Text(source); len(alt) > 0 {
-			_, _ = w.WriteString(`" alt="`)
-			_, _ = w.Write(util.EscapeHTML(alt))
-			_ = w.WriteByte('"')
-		}
-
-		if n.Title != nil {
-			_, _ = w.WriteString(` title="`)
-			_, _ = w.Write(n.Title)
-			_ = w.WriteByte('"')
-		}
-
-		_, _ = w.WriteString("/>")
-
-		return ast.WalkSkipChildren, nil
-	})
-
-	reg.Register(ast.KindBlockquote, renderTag("blockquote", html.BlockquoteAttributeFilter))
-
-	renderDiv := renderTag("div", html.GlobalAttributeFilter)
-	noop := func(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
-		return ast.WalkContinue, nil
+func init() {
+	// set local time zone to the time zone
+	// the example page was exported to
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
 	}
 
-	reg.Register(n_ast.KindSyncedBlock, renderDiv)
-	reg.Register(extast.KindTaskCheckBox, renderDiv)
-
-	idFilter := util.NewBytesFilter([]byte("id"))
-
-	// notion prints all list items in their own list
-	reg.Register(ast.KindList, noop)
-	reg.Register(ast.KindListItem, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		p := n.Parent().(*ast.List)
-
-		tag := "ul"
-		if p.IsOrdered() {
-			tag = "ol"
-		}
-
-		if !entering {
-			_, _ = w.WriteString("</li></")
-			_, _ = w.WriteString(tag)
-			_ = w.WriteByte('>')
-			return ast.WalkContinue, nil
-		}
-
-		_ = w.WriteByte('<')
-		_, _ = w.WriteString(tag)
-
-		depth := listDepth(p, p.IsOrdered())
-
-		if p.IsOrdered() {
-			// different types depending on depth
-			_, _ = w.WriteString(` type="`)
-			_, _ = w.WriteString([]string{"1", "a", "i"}[depth%3])
-			_ = w.WriteByte('"')
-		}
-
-		// html.RenderAttributes(w, p, typeFilter)
-		html.RenderAttributes(w, n, idFilter)
-
-		// e.g. class="block-color-red_background numbered-list" or class="red to-do-list"
-		renderClass(w, n, p)
-
-		if p.IsOrdered() {
-			_, _ = w.WriteString(` start="`)
-			_, _ = w.WriteString(strconv.Itoa(numPrevious(n) + 1))
-			_ = w.WriteByte('"')
-		}
-
-		isToDo := false
-		if c := n.FirstChild(); c != nil && c.Kind() == extast.KindTaskCheckBox {
-			isToDo = true
-		}
-
-		_, _ = w.WriteString("><li")
-		if !p.IsOrdered() && !isToDo {
-			// different styles depending on depth
-			_, _ = w.WriteString(` style="list-style-type:`)
-			_, _ = w.WriteString([]string{"disc", "circle", "square"}[depth%3])
-			_ = w.WriteByte('"')
-		}
-
-		_ = w.WriteByte('>')
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(n_ast.KindCheckboxText, renderTag("span", html.ListItemAttributeFilter))
-
-	reg.Register(n_ast.KindToggle, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString("</details></li></ul>")
-			return ast.WalkContinue, nil
-		}
-
-		_, _ = w.WriteString("<ul")
-		html.RenderAttributes(w, n, idFilter)
-		renderClass(w, n)
-		_, _ = w.WriteString(`><li><details open="">`)
-
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(n_ast.KindToggleText, renderTag("summary", html.GlobalAttributeFilter))
-
-	reg.Register(ast.KindFencedCodeBlock, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString("</code></pre>")
-			return ast.WalkContinue, nil
-		}
-
-		_, _ = w.WriteString("<pre")
-		html.RenderAttributes(w, n, html.CodeAttributeFilter)
-		_, _ = w.WriteString("><code>")
-
-		return ast.WalkContinue, nil
-	})
+	time.Local = loc
+}
 
-	renderLink := func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString("</a>")
-			return ast.WalkContinue, nil
-		}
+func pageRoot(name string, id notion.Id) string {
+	return fmt.Sprintf("%s %s", name, strings.ReplaceAll(string(id), "-", ""))
+}
 
-		n := node.(*ast.Link)
+// getExamplePage returns the example page as a document together with its source.
+func getExamplePage(t *testing.T, opts ...Option) (*ast.Document, []byte) {
+	t.Helper()
 
-		_, _ = w.WriteString(`<a href="`)
-		_, _ = w.Write(util.EscapeHTML(n.Destination))
-		_ = w.WriteByte('"')
+	g := fakeGetter(t)
 
-		if n.Title != nil {
-			_, _ = w.WriteString(` title="`)
-			_, _ = w.Write(n.Title)
-			_ = w.WriteByte('"')
+	database, entries := g.database, g.databaseEntries
+	linked := func(id notion.Id) notion.Id {
+		if id == linkedDatabaseID {
+			return databaseID
 		}
 
-		html.RenderAttributes(w, n, html.LinkAttributeFilter)
-
-		_ = w.WriteByte('>')
-
-		return ast.WalkContinue, nil
+		return id
 	}
 
-	reg.Register(ast.KindLink, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		// don't render links in fenced code blocks
-		for p := node.Parent(); p != nil; p = p.Parent() {
-			if p.Kind() == ast.KindFencedCodeBlock {
-				return ast.WalkContinue, nil
-			}
-		}
-
-		return renderLink(w, nil, node, entering)
-	})
+	g.database = func(id notion.Id) (*notion.Database, error) { return database(linked(id)) }
+	g.databaseEntries = func(id notion.Id) (notion.Pages, error) { return entries(linked(id)) }
 
-	reg.Register(n_ast.KindChildPage, renderFigure)
-	reg.Register(n_ast.KindEmbed, renderFigure)
-
-	reg.Register(n_ast.KindEmbedSource, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString("</div>")
-			return ast.WalkContinue, nil
+	// the files are not actually downloaded and example.com is served locally
+	cli := &http.Client{Transport: &testRoundtripper{roundTrip: func(r *http.Request) (*http.Response, error) {
+		if r.URL.String() != "https://example.com/" {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
 		}
 
-		_, _ = w.WriteString(`<div class="source">`)
-
-		return ast.WalkContinue, nil
-	})
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+			Body:       io.NopCloser(strings.NewReader(exampleDomain)),
+			Request:    r,
+		}, nil
+	}}}
+
+	doc, source, err := NewConverter(g, append(opts,
+		WithAssetStore(NewFSAssetStore(afero.NewMemMapFs(), cli)),
+		WithMetadataProvider(NewHTMLMetadataProvider(cli)),
+		// files with the same name are numbered in the order they are stored
+		WithConcurrency(1),
+		// Notion does not export breadcrumbs, templates and unsupported blocks either
+		WithUnsupportedPolicy(UnsupportedSkip))...).Page(context.Background(), fake.PageID)
+	assert.NoError(t, err)
 
-	reg.Register(n_ast.KindCaption, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		// notion ignores the caption of fenced code blocks
-		if n.Parent().Kind() == ast.KindFencedCodeBlock {
-			return ast.WalkSkipChildren, nil
-		}
+	return doc, source
+}
 
-		return renderTag("figcaption", html.GlobalAttributeFilter)(w, source, n, entering)
-	})
+var (
+	imageWidth = regexp.MustCompile(`<img style="width:\d+px"`)
 
-	reg.Register(n_ast.KindChildDatabase, renderDiv)
+	// Notion gives the cells of simple tables random IDs and fixed widths
+	cellID    = regexp.MustCompile(`(<t[dh]) id="[^"]*"`)
+	cellWidth = regexp.MustCompile(`(<t[dh][^>]*) style="width:\d+px"`)
 
-	reg.Register(extast.KindTable, renderTag("table", extension.TableAttributeFilter))
+	// Notion's export only links to audio and video files
+	player = regexp.MustCompile(`<(?:audio|video) controls="" src="[^"]*">(.*?)</(?:audio|video)>`)
 
-	reg.Register(extast.KindTableHeader, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if entering {
-			_, _ = w.WriteString("<thead")
-			html.RenderAttributes(w, n, extension.TableHeaderAttributeFilter)
-			_, _ = w.WriteString("><tr>") // Header <tr> has no separate handle
-		} else {
-			_, _ = w.WriteString("</tr>")
-			_, _ = w.WriteString("</thead>")
-			if n.NextSibling() != nil {
-				_, _ = w.WriteString("<tbody>")
-			}
-		}
+	// Notion imports the KaTeX stylesheet with every equation
+	mathStyle = regexp.MustCompile(`<style>@import url\('[^']*katex[^']*'\)</style>`)
+)
 
-		return ast.WalkContinue, nil
-	})
+// htmlBlock is a top level element of a page.
+type htmlBlock struct {
+	id, html string
+}
 
-	reg.Register(extast.KindTableRow, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if entering {
-			_, _ = w.WriteString("<tr")
-			html.RenderAttributes(w, n, extension.TableRowAttributeFilter)
-			_, _ = w.WriteString(">")
-		} else {
-			_, _ = w.WriteString("</tr>")
+// voidElements have no end tag.
+var voidElements = map[string]bool{
+	"br": true, "col": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "source": true, "wbr": true,
+}
 
-			if n.Parent().LastChild() == n {
-				_, _ = w.WriteString("</tbody>")
-			}
+// splitBlocks splits the HTML of a page into its top level elements.
+func splitBlocks(b []byte) []htmlBlock {
+	l := nhtml.NewLexer(parse.NewInputBytes(b))
+
+	var (
+		blocks   []htmlBlock
+		cur      bytes.Buffer
+		depth    int
+		tag, id  string
+		complete bool
+	)
+
+	for {
+		tt, data := l.Next()
+		if tt == nhtml.ErrorToken {
+			return blocks
 		}
-		return ast.WalkContinue, nil
-	})
 
-	reg.Register(extast.KindTableCell, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		n := node.(*extast.TableCell)
+		cur.Write(data)
 
-		tag := "td"
-		if n.Parent().Kind() == extast.KindTableHeader {
-			tag = "th"
-		}
-
-		if entering {
-			fmt.Fprintf(w, "<%s", tag)
-			if n.Alignment != extast.AlignNone {
-				// amethod := r.TableConfig.TableCellAlignMethod
-				// if amethod == TableCellAlignDefault {
-				// 	if r.Config.XHTML {
-				// 		amethod = TableCellAlignAttribute
-				// 	} else {
-				// 		amethod = TableCellAlignStyle
-				// 	}
-				// }
-				// switch amethod {
-				// case TableCellAlignAttribute:
-				if _, ok := n.AttributeString("align"); !ok { // Skip align render if overridden
-					fmt.Fprintf(w, ` align="%s"`, n.Alignment.String())
-				}
-				// case TableCellAlignStyle:
-				// 	v, ok := n.AttributeString("style")
-				// 	var cob util.CopyOnWriteBuffer
-				// 	if ok {
-				// 		cob = util.NewCopyOnWriteBuffer(v.([]byte))
-				// 		cob.AppendByte(';')
-				// 	}
-				// 	style := fmt.Sprintf("text-align:%s", n.Alignment.String())
-				// 	cob.AppendString(style)
-				// 	n.SetAttributeString("style", cob.Bytes())
-				// }
+		switch tt {
+		case nhtml.StartTagToken:
+			tag = string(l.Text())
+		case nhtml.AttributeToken:
+			if depth == 0 && id == "" && string(l.Text()) == "id" {
+				id = string(bytes.Trim(l.AttrVal(), `"`))
 			}
-			if tag == "td" {
-				html.RenderAttributes(w, n, extension.TableTdCellAttributeFilter) // <td>
+		case nhtml.StartTagCloseToken:
+			if voidElements[tag] {
+				complete = depth == 0
 			} else {
-				html.RenderAttributes(w, n, extension.TableThCellAttributeFilter) // <th>
+				depth++
 			}
-			_ = w.WriteByte('>')
-		} else {
-			fmt.Fprintf(w, "</%s>", tag)
+		case nhtml.StartTagVoidToken:
+			complete = depth == 0
+		case nhtml.EndTagToken:
+			depth--
+			complete = depth == 0
+		case nhtml.TextToken:
+			complete = depth == 0
 		}
 
-		return ast.WalkContinue, nil
-	})
-
-	reg.Register(n_ast.KindPropertyIcon, renderTag("span", html.GlobalAttributeFilter))
-	reg.Register(n_ast.KindSVG, renderTag("svg", svgFilter))
-	reg.Register(n_ast.KindSVGPath, renderTag("path", pathFilter))
-	reg.Register(n_ast.KindPolygon, renderTag("polygon", polygonFilter))
-	reg.Register(n_ast.KindStatus, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString(`</span>`)
-			return ast.WalkContinue, nil
+		if complete {
+			blocks = append(blocks, htmlBlock{id: id, html: cur.String()})
+			cur.Reset()
+			id, complete = "", false
 		}
+	}
+}
 
-		data := node.(*n_ast.Status).Data
-
-		_, _ = w.WriteString(`<span class="status-value select-value-color-`)
-		_, _ = w.WriteString(string(data.Color))
-		_, _ = w.WriteString(`"><div class="status-dot status-dot-color-`)
-		_, _ = w.WriteString(string(data.Color))
-		_, _ = w.WriteString(`"></div>`)
-		_, _ = w.WriteString(data.Name)
-
-		return ast.WalkSkipChildren, nil
-	})
-
-	reg.Register(n_ast.KindDate, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString(`</time>`)
-			return ast.WalkContinue, nil
-		}
-
-		_, _ = w.WriteString(`<time>`)
-
-		n := node.(*n_ast.Date)
-
-		if n.Date == nil {
-			return ast.WalkContinue, nil
-		}
+func TestFromBlock(t *testing.T) {
+	t.Parallel()
 
-		_ = w.WriteByte('@')
+	doc, source := getExamplePage(t)
 
-		_, _ = w.WriteString(formatTime(n.Date.Start, n.TwelveHourClock))
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
 
-		if n.Date.End == nil {
-			return ast.WalkContinue, nil
-		}
+	html, err := fake.HTMLExport.ReadFile("html/" + pageRoot("Example Page", fake.PageID) + ".html")
+	assert.NoError(t, err)
 
-		_, _ = w.WriteString(" → ")
-		_, _ = w.WriteString(formatTime(*n.Date.End, n.TwelveHourClock))
+	body := []byte(`<div class="page-body">`)
+	start := bytes.Index(html, body)
+	if !assert.NotEqual(t, -1, start) {
+		return
+	}
 
-		return ast.WalkContinue, nil
-	})
+	html = bytes.TrimSuffix(html[start+len(body):], []byte("</div></article></body></html>"))
 
-	reg.Register(n_ast.KindFileInCell, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString(`</span>`)
-			return ast.WalkContinue, nil
-		}
+	// the API does not tell us the width notion displays images with
+	html = imageWidth.ReplaceAll(html, []byte("<img"))
+	html = cellWidth.ReplaceAll(cellID.ReplaceAll(html, []byte("$1")), []byte("$1"))
 
-		_, _ = w.WriteString(`<span style="margin-right:6px">`)
+	got := player.ReplaceAll(mathStyle.ReplaceAll(w.Bytes(), nil), []byte(`<div class="source">$1</div>`))
 
-		return ast.WalkContinue, nil
-	})
+	skip := map[string]bool{
+		// Notion typesets equations, we leave that to KaTeX in the browser
+		"a310fbc6-aa14-4c88-bdf4-5bf939b0b69b": true,
+		"fed8f526-d79c-4429-9ca9-c6aa55e7044b": true,
+		// the columns are in the order of the database view, which the API does not tell us
+		linkedDatabaseID: true,
+		// the API returns this link to a page as an unsupported block
+		"df90220c-36b9-4024-bb8e-5297d2affae3": true,
+	}
 
-	reg.Register(n_ast.KindUser, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString(`</span>`)
-			return ast.WalkContinue, nil
+	filter := func(blocks []htmlBlock) (ids []string, filtered []htmlBlock) {
+		for _, b := range blocks {
+			if !skip[b.id] {
+				ids = append(ids, b.id)
+				filtered = append(filtered, b)
+			}
 		}
 
-		user := node.(*n_ast.User).Data
-
-		_, _ = w.WriteString(`<span class="user">`)
+		return ids, filtered
+	}
 
-		if user.AvatarUrl != nil {
-			_, _ = w.WriteString(`<img src="`)
-			_, _ = w.WriteString(*user.AvatarUrl)
-			_, _ = w.WriteString(`" class="icon user-icon"/>`)
-		}
+	wantIDs, want := filter(splitBlocks(html))
+	gotIDs, gotBlocks := filter(splitBlocks(got))
 
-		if user.Name != nil {
-			_, _ = w.WriteString(*user.Name)
-		}
+	if !assert.Equal(t, wantIDs, gotIDs) {
+		return
+	}
 
-		return ast.WalkContinue, nil
-	})
+	for i, b := range want {
+		assert.Equal(t, b.html, gotBlocks[i].html, "block %q", b.id)
+	}
+}
 
-	reg.Register(n_ast.KindSelect, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			_, _ = w.WriteString(`</span>`)
-			return ast.WalkContinue, nil
-		}
+func TestPageHeader(t *testing.T) {
+	t.Parallel()
 
-		val := node.(*n_ast.Select).Data
+	doc, source := getExamplePage(t, WithPageHeader())
+
+	meta := GetPageMeta(doc)
+	if assert.NotNil(t, meta) {
+		assert.Equal(t, notion.UUID(fake.PageID), meta.ID)
+		assert.Equal(t, "Example Page", meta.Title)
+		assert.Equal(t, "🌄", *meta.Icon.Emoji)
+		assert.Equal(t, notion.FileTypeExternal, meta.Cover.Type)
+		assert.False(t, meta.CreatedTime.IsZero())
+		assert.True(t, meta.LastEditedTime.After(meta.CreatedTime))
+		assert.Contains(t, meta.Properties, "title")
+	}
 
-		_, _ = w.WriteString(`<span class="selected-value`)
+	html, err := fake.HTMLExport.ReadFile("html/" + pageRoot("Example Page", fake.PageID) + ".html")
+	assert.NoError(t, err)
 
-		switch val.Color {
-		case notion.ColorDefault, "": // no color
-		default:
-			_, _ = w.WriteString(` select-value-color-`)
-			_, _ = w.WriteString(string(val.Color))
-		}
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
 
-		_, _ = w.WriteString(`">`)
-		_, _ = w.WriteString(val.Name)
+	header := regexp.MustCompile(`<header>.*?</header>`)
+	assert.Equal(t, string(header.Find(html)), header.FindString(w.String()))
+	// only the stylesheet of the equations comes before the header
+	assert.True(t, strings.HasPrefix(mathStyle.ReplaceAllString(w.String(), ""), "<header>"))
 
-		return ast.WalkContinue, nil
-	})
+	// without the option, only the metadata is available
+	doc, _ = getExamplePage(t)
+	assert.NotEqual(t, n_ast.KindPageHeader, doc.FirstChild().Kind())
+	assert.NotNil(t, GetPageMeta(doc))
 }
 
-var (
-	svgFilter     = html.GlobalAttributeFilter.Extend([]byte("viewBox"))
-	pathFilter    = util.NewBytesFilter([]byte("d"))
-	polygonFilter = util.NewBytesFilter([]byte("points"))
-)
-
-func formatTime(ts time.Time, twelveHourClock bool) string {
-	if ts.Minute() == 0 && ts.Hour() == 0 {
-		return ts.Format("January 2, 2006")
-	}
-
-	if twelveHourClock {
-		return ts.Local().Format("January 2, 2006 3:04 PM")
-	}
+func TestTable(t *testing.T) {
+	t.Parallel()
 
-	// we need to remove the zero
-	// e.g. "August 12, 2022 03:00" -> "August 12, 2022 3:00"
-	return strings.Replace(ts.Local().Format("January 2, 2006 15:04"), " 0", " ", 1)
-}
+	ctx := context.Background()
 
-// renderTag factories out a simple function to render a tag
-func renderTag(tagName string, filter util.BytesFilter) func(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
-	start := "<" + tagName
-	end := "</" + tagName + ">"
-
-	return func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if entering {
-			_, _ = w.WriteString(start)
-			html.RenderAttributes(w, n, filter)
-			_ = w.WriteByte('>')
-		} else {
-			_, _ = w.WriteString(end)
+	tableRow := func(id notion.UUID, cells ...string) notion.Block {
+		row := &notion.TableRow{}
+		for _, c := range cells {
+			row.Cells = append(row.Cells, notion.NewRichTexts(c))
 		}
 
-		return ast.WalkContinue, nil
+		return notion.Block{Id: id, Type: notion.BlockTypeTableRow, TableRow: row}
 	}
-}
 
-func listDepth(l ast.Node, ordered bool) int {
-	switch p := l.Parent().(type) {
-	case *ast.List:
-		if p.IsOrdered() != ordered {
-			return 0
-		}
-
-		return listDepth(p, ordered) + 1
-	case *n_ast.BlockChildren, *ast.ListItem:
-		return listDepth(p, ordered)
-	default:
-		return 0
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			switch id {
+			case "page":
+				return notion.Blocks{{
+					Id: "table", Type: notion.BlockTypeTable, HasChildren: true,
+					Table: &notion.Table{HasColumnHeader: true, HasRowHeader: true, TableWidth: 2},
+				}}, nil
+			case "table":
+				return notion.Blocks{
+					tableRow("row1", "Person", "Wortform"),
+					tableRow("row2", "ich", "bin"),
+					tableRow("row3", "du", "bist"),
+					tableRow("row4", "er | sie", "ist\nsind"),
+				}, nil
+			default:
+				return nil, fmt.Errorf("unknown block %q", id)
+			}
+		},
 	}
-}
-
-func renderClass(w util.BufWriter, nodes ...ast.Node) {
-	_, _ = w.WriteString(` class="`)
-
-	classes := [][]byte{}
-
-	for _, n := range nodes {
-		cl, _ := n.AttributeString("class")
-		class, _ := cl.([]byte)
 
-		if len(class) == 0 {
-			continue
-		}
-
-		classes = append(classes, class)
-	}
+	doc, source, err := NewConverter(cli).Page(ctx, "page")
+	assert.NoError(t, err)
 
-	_, _ = w.Write(bytes.Join(classes, []byte{' '}))
-	_ = w.WriteByte('"')
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
+	assert.Equal(t, `<table id="table" class="simple-table">`+
+		`<thead class="simple-table-header"><tr id="row1">`+
+		`<th class="simple-table-header-color simple-table-header">Person</th>`+
+		`<th class="simple-table-header-color simple-table-header">Wortform</th>`+
+		`</tr></thead><tbody>`+
+		`<tr id="row2"><th class="simple-table-header-color simple-table-header">ich</th><td class="">bin</td></tr>`+
+		`<tr id="row3"><th class="simple-table-header-color simple-table-header">du</th><td class="">bist</td></tr>`+
+		`<tr id="row4"><th class="simple-table-header-color simple-table-header">er | sie</th><td class="">ist`+"\n"+`sind</td></tr>`+
+		`</tbody></table>`, w.String())
+
+	w.Reset()
+	assert.NoError(t, markdown.New().Render(w, source, doc))
+	assert.Equal(t, `| Person | Wortform |
+| --- | --- |
+| ich | bin |
+| du | bist |
+| er \| sie | ist<br>sind |
+`, w.String())
 }
 
-func TestFromBlock(t *testing.T) {
+func TestColumns(t *testing.T) {
 	t.Parallel()
 
-	// set local time zone to the time zone
-	// the example page was exported to
-	loc, err := time.LoadLocation("Europe/Berlin")
-	assert.NoError(t, err)
-
-	time.Local = loc
-
 	ctx := context.Background()
 
-	cli, _, err := fake.NewClient()
-	assert.NoError(t, err)
-
-	doc := ast.NewDocument()
-
-	nodes, err := GetPage(ctx, cli, fake.PageID, max)
-	assert.NoError(t, err)
-
-	for _, n := range nodes {
-		doc.AppendChild(doc, n)
+	paragraph := func(id notion.UUID, txt string) notion.Block {
+		b := paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts(txt)})
+		b.Id = id
+		return b
 	}
 
-	pageRoot := func(name string, id notion.Id) string {
-		return fmt.Sprintf("%s %s", name, strings.ReplaceAll(string(id), "-", ""))
+	column := func(id notion.UUID) notion.Block {
+		return notion.Block{Id: id, Type: notion.BlockTypeColumn, HasChildren: true}
 	}
 
-	root := pageRoot("Example Page", fake.PageID)
-
-	assert.NoError(t, ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
-		switch n := node.(type) {
-		case *ast.Image:
-			if _, ok := n.AttributeString("expires"); ok {
-				u, err := url.Parse(string(n.Destination))
-				if err != nil {
-					return 0, err
-				}
-
-				// download the file to root with fileName here
-				fileName := filepath.Base(u.Path)
-
-				n.Destination = util.URLEscape([]byte(filepath.Join(root, fileName)), true)
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			switch id {
+			case "page":
+				return notion.Blocks{
+					{Id: "list", Type: notion.BlockTypeColumnList, HasChildren: true},
+					paragraph("after", "After"),
+				}, nil
+			case "list":
+				return notion.Blocks{column("col1"), column("col2"), column("col3")}, nil
+			case "col1":
+				return notion.Blocks{paragraph("p1", "One"), paragraph("p2", "Two")}, nil
+			case "col2":
+				return notion.Blocks{paragraph("p3", "Three")}, nil
+			case "col3":
+				return notion.Blocks{paragraph("p4", "Four")}, nil
+			default:
+				return nil, fmt.Errorf("unknown block %q", id)
 			}
-		}
-
-		return 0, nil
-	}))
+		},
+	}
 
-	// doc.Dump(nil, 0)
+	doc, source, err := NewConverter(cli).Page(ctx, "page")
+	assert.NoError(t, err)
 
 	w := &bytes.Buffer{}
-	assert.NoError(t, r.Render(w, nil, doc))
-	got := w.Bytes()
+	assert.NoError(t, r.Render(w, source, doc))
+	assert.Equal(t, `<div id="list" class="column-list">`+
+		`<div id="col1" style="width:33.333333333333336%" class="column"><p id="p1" class="">One</p><p id="p2" class="">Two</p></div>`+
+		`<div id="col2" style="width:33.333333333333336%" class="column"><p id="p3" class="">Three</p></div>`+
+		`<div id="col3" style="width:33.333333333333336%" class="column"><p id="p4" class="">Four</p></div>`+
+		`</div><p id="after" class="">After</p>`, w.String())
+
+	w.Reset()
+	assert.NoError(t, markdown.New().Render(w, source, doc))
+	assert.Equal(t, "One\n\nTwo\n\nThree\n\nFour\n\nAfter\n", w.String())
+}
 
-	start := 14705
-	html, err := fake.HTMLExport.ReadFile("html/" + root + ".html")
-	want := html[start:]
+func TestFiles(t *testing.T) {
+	t.Parallel()
 
-	for i, b := range want {
-		if len(got) > i && got[i] == b {
-			continue
+	ctx := context.Background()
+
+	uploaded := func(name string) notion.FileWithCaption {
+		return notion.FileWithCaption{
+			Type: notion.FileWithCaptionTypeFile,
+			File: &notion.NotionFile{
+				Url:        "https://s3.us-west-2.amazonaws.com/secure.notion-static.com/abc/" + name + "?X-Amz-Signature=123",
+				ExpiryTime: time.Date(2022, 7, 27, 20, 3, 51, 0, time.UTC),
+			},
 		}
+	}
 
-		k := i - 6
+	caption := notion.NewRichTexts("Listen!")
+	video := uploaded("small.mp4")
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{
+				{Id: "video", Type: notion.BlockTypeVideo, Video: &notion.Video{
+					Type: notion.VideoType(video.Type), File: video.File,
+				}},
+				{Id: "audio", Type: notion.BlockTypeAudio, Audio: &notion.FileWithCaption{
+					Type:     notion.FileWithCaptionTypeExternal,
+					External: &notion.ExternalFile{Url: "https://example.com/notion.ogg"},
+					Caption:  &caption,
+				}},
+				{Id: "file", Type: notion.BlockTypeFile, File: func() *notion.FileWithCaption {
+					f := uploaded("report.pdf")
+					return &f
+				}()},
+			}, nil
+		},
+	}
 
-		offset := 3
-		end := i + bytes.Index(want[i+offset:], []byte(">")) + 1 + offset
+	doc, source, err := NewConverter(cli).Page(ctx, "page")
+	assert.NoError(t, err)
 
-		assert.Equal(t, string(want[k:end]), string(got[k:]), "from %d", k)
-		break
+	f, ok := doc.FirstChild().(*n_ast.File)
+	if assert.True(t, ok) {
+		assert.True(t, f.IsVideo())
+		assert.False(t, f.External)
+		assert.Equal(t, "small.mp4", f.Name)
+		assert.Equal(t, time.Date(2022, 7, 27, 20, 3, 51, 0, time.UTC), f.Expires)
+
+		f.SetDestination([]byte("page/small.mp4"))
 	}
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
+	assert.Equal(t, `<figure id="video"><video controls="" src="page/small.mp4">`+
+		`<a href="page/small.mp4">https://s3-us-west-2.amazonaws.com/secure.notion-static.com/abc/small.mp4</a></video></figure>`+
+		`<figure id="audio"><audio controls="" src="https://example.com/notion.ogg">`+
+		`<a href="https://example.com/notion.ogg">https://example.com/notion.ogg</a></audio>`+
+		`<figcaption>Listen!</figcaption></figure>`+
+		`<figure id="file"><div class="source">`+
+		`<a href="https://s3.us-west-2.amazonaws.com/secure.notion-static.com/abc/report.pdf?X-Amz-Signature=123">`+
+		`https://s3-us-west-2.amazonaws.com/secure.notion-static.com/abc/report.pdf</a></div></figure>`, w.String())
+
+	w.Reset()
+	assert.NoError(t, markdown.New().Render(w, source, doc))
+	assert.Equal(t, `[small.mp4](page/small.mp4)
+
+[Listen!](https://example.com/notion.ogg)
+
+[report.pdf](https://s3.us-west-2.amazonaws.com/secure.notion-static.com/abc/report.pdf?X-Amz-Signature=123)
+`, w.String())
 }
 
 func paragraphBlock(p *notion.Paragraph) notion.Block {
@@ -683,15 +458,6 @@ func paragraphBlock(p *notion.Paragraph) notion.Block {
 	}
 }
 
-func numPrevious(n ast.Node) int {
-	prev := n.PreviousSibling()
-	if prev == nil {
-		return 0
-	}
-
-	return numPrevious(prev) + 1
-}
-
 // var testBlocks = notion.Blocks{
 // 	paragraphBlock(&notion.Paragraph{
 // 		Color: notion.ColorGreen,
@@ -758,34 +524,109 @@ func numPrevious(n ast.Node) int {
 func TestMarkdown(t *testing.T) {
 	t.Parallel()
 
-	// doc := ast.NewDocument()
-	// for _, b := range FromBlocks(testBlocks) {
-	// 	doc.AppendChild(doc, b)
-	// }
+	doc, source := getExamplePage(t, WithPageHeader())
 
-	// b, err := hugo.Render(nil, doc)
-	// 	assert.NoError(t, err)
-	// 	assert.Equal(t, `<span class=green>This is the **home** page.
+	fs := afero.NewMemMapFs()
 
-	// </span>
+	w := &bytes.Buffer{}
+	assert.NoError(t, markdown.New(markdown.WithFS(fs)).Render(w, source, doc))
+	gotMD := w.String()
+
+	// the markdown export was made of a duplicate of the example page
+	exportRoot := pageRoot("Example Page", "3b4d96f0-a5e2-4e47-90eb-dbc3f95f936d")
+	normalize := func(b []byte) string {
+		return strings.ReplaceAll(string(b),
+			"3b4d96f0a5e24e4790ebdbc3f95f936d", "96245c8f178444a482ad1941127c3ec3")
+	}
 
-	// $\sum 2+2 = 4\sigma$
+	b, err := fake.MDCSVExport.ReadFile("md-csv/" + exportRoot + ".md")
+	assert.NoError(t, err)
 
-	// Mark Rösler is a great guy.
-	// `, string(b))
-}
+	wantMD := normalize(b)
+
+	wantMD = strings.NewReplacer(
+		// code is plain text, but notion keeps the links in code blocks
+		"\t[startNotion](https://developers.notion.com/reference/block)()", "\tstartNotion()",
+		// the API returns this link to a page as an unsupported block
+		"[Getting Started](https://www.notion.so/Getting-Started-df90220c36b94024bb8e5297d2affae3)\n\n", "",
+		// notion links mentioned databases to their CSV file without their title
+		"[](Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20Child%20Database%207a3c647e4c1e4c27bf1dcfb0105e55ce.csv) ",
+		"[My Child Database](Example%20Page%2096245c8f178444a482ad1941127c3ec3/My%20Child%20Database%207a3c647e4c1e4c27bf1dcfb0105e55ce.md)",
+		// notion drops the formatting of table cells
+		"| Person |", "| [Person](https://de.wiktionary.org/wiki/sein) |",
+		"| 6 | 8 | 10 |", "| 6 | **8** | 10 |",
+		"| 9 | 12 | 15 |", "| 9 | [12](https://github.com/faetools/go-notion) | 15 |",
+	).Replace(wantMD) + "\n"
+
+	// notion leaves the references to the synced block out of the markdown export
+	synced := "This is synced.\n\n- [x]  Create three of these.\n\n"
+	if i := strings.Index(gotMD, synced) + len(synced); assert.GreaterOrEqual(t, i, len(synced)) {
+		gotMD = gotMD[:i] + strings.ReplaceAll(gotMD[i:], synced, "")
+	}
+	assert.Equal(t, wantMD, gotMD)
 
-var r = goldmark.DefaultRenderer()
+	// child databases are exported as CSV files
+	root := pageRoot("Example Page", fake.PageID)
+	db := pageRoot("My Child Database", "7a3c647e-4c1e-4c27-bf1d-cfb0105e55ce")
 
-func init() {
-	r.AddOptions(renderer.WithNodeRenderers(
-		util.Prioritized(extension.NewStrikethroughHTMLRenderer(), 100),
-		// util.Prioritized(extension.NewTableHTMLRenderer(), 99),
-		util.Prioritized(&htmlRenderer{}, 100),
-	))
+	gotCSV, err := afero.ReadFile(fs, root+"/"+db+".csv")
+	assert.NoError(t, err)
+
+	b, err = fake.MDCSVExport.ReadFile("md-csv/" + exportRoot + "/" + db + ".csv")
+	assert.NoError(t, err)
+
+	want, err := csv.NewReader(strings.NewReader(normalize(b))).ReadAll()
+	assert.NoError(t, err)
+
+	got, err := csv.NewReader(bytes.NewReader(gotCSV)).ReadAll()
+	assert.NoError(t, err)
+
+	if !assert.Len(t, got, len(want)) {
+		return
+	}
+
+	// the header
+	assert.Equal(t, want[0], got[0])
+
+	for i := range want {
+		// relations link to the entries
+		assert.Equal(t, want[i][2], got[i][2])
+		// checkboxes
+		assert.Equal(t, want[i][4], got[i][4])
+	}
 }
 
-type htmlRenderer struct{}
+var r = goldmark.New(goldmark.WithExtensions(notionhtml.Notion)).Renderer()
+
+func TestEscapeHTML(t *testing.T) {
+	t.Parallel()
+
+	script := "<script>alert('hi')</script>"
+	escaped := "&lt;script&gt;alert(&#x27;hi&#x27;)&lt;/script&gt;"
+
+	link := ast.NewLink()
+	link.Destination = []byte("https://example.com")
+	link.Title = []byte(`"` + script)
+
+	p := ast.NewParagraph()
+	p.AppendChild(p, &n_ast.Select{Data: &notion.SelectValue{Name: script, Color: notion.ColorRed}})
+	p.AppendChild(p, &n_ast.Status{Data: &notion.SelectValue{Name: script, Color: notion.ColorRed}})
+	p.AppendChild(p, &n_ast.User{Data: notion.User{Name: &script, AvatarUrl: &script}})
+	p.AppendChild(p, link)
+
+	doc := ast.NewDocument()
+	doc.AppendChild(doc, p)
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, nil, doc))
+
+	got := w.String()
+	assert.NotContains(t, got, "<script>")
+	assert.Contains(t, got, `<span class="selected-value select-value-color-red">`+escaped+`</span>`)
+	assert.Contains(t, got, `<div class="status-dot status-dot-color-red"></div>`+escaped+`</span>`)
+	assert.Contains(t, got, `<img src="`+escaped+`" class="icon user-icon"/>`+escaped+`</span>`)
+	assert.Contains(t, got, `title="&quot;`+escaped+`"`)
+}
 
 // func TestTransform(t *testing.T) {
 // 	t.Parallel()
@@ -818,17 +659,383 @@ type htmlRenderer struct{}
 // 	assert.ErrorIs(t, err, testError)
 // }
 
-func TestTransform_Panics(t *testing.T) {
+func TestTableOfContents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	blocks, err := cli.GetAllBlocks(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	// the headings, a callout with a nested heading and both tables of contents
+	g := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) { return cli.GetNotionPage(ctx, id) },
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			if id != fake.PageID {
+				return cli.GetAllBlocks(ctx, id)
+			}
+
+			return notion.Blocks{blocks[9], blocks[10], blocks[11], blocks[13], blocks[46], blocks[47]}, nil
+		},
+	}
+
+	doc, source, err := NewConverter(g).Page(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
+
+	html, err := fake.HTMLExport.ReadFile("html/" + pageRoot("Example Page", fake.PageID) + ".html")
+	assert.NoError(t, err)
+
+	want := regexp.MustCompile(`<nav.*?</nav>`).FindAll(html, -1)
+	got := regexp.MustCompile(`<nav.*?</nav>`).FindAll(w.Bytes(), -1)
+
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, string(want[0]), string(got[0]))
+		assert.Equal(t, string(want[1]), string(got[1]))
+	}
+}
+
+func TestTableOfContents_Links(t *testing.T) {
 	t.Parallel()
-	// ctx := context.Background()
-
-	// assert.PanicsWithValue(t, `unknown node notion block type "foo"`, func() {
-	// 	_, _ = FromBlocks(ctx, &testGetter{
-	// 		blocks: func(id notion.Id) (notion.Blocks, error) {
-	// 			return notion.Blocks{{Type: "foo"}}, nil
-	// 		},
-	// 	}, "")
-	// })
+
+	ctx := context.Background()
+
+	href := "https://example.com"
+	link := notion.NewRichText("a link")
+	link.Href = &href
+
+	mention := notion.NewRichText("a page")
+	mention.Type = notion.RichTextTypeMention
+	mention.Mention = &notion.Mention{Type: notion.MentionTypePage, Page: &notion.Reference{Id: "other"}}
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id), Parent: &notion.Parent{Type: notion.ParentTypeWorkspace}}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{
+				{Id: "toc", Type: notion.BlockTypeTableOfContents, TableOfContents: &notion.TableOfContents{Color: notion.ColorDefault}},
+				{Id: "heading", Type: notion.BlockTypeHeading1, Heading1: &notion.Paragraph{RichText: notion.RichTexts{
+					notion.NewRichText("With "), link, notion.NewRichText(" and "), mention,
+					{Type: "bar", PlainText: "baz"},
+				}}},
+			}, nil
+		},
+	}
+
+	doc, source, err := NewConverter(cli, WithUnsupportedPolicy(UnsupportedSkip)).Page(ctx, "page")
+	assert.NoError(t, err)
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, r.Render(w, source, doc))
+
+	toc := regexp.MustCompile(`<nav.*?</nav>`).Find(w.Bytes())
+
+	// the headings are linked, so their links are left out
+	assert.Contains(t, string(toc), `href="#heading">With a link and a page</a>`)
+	assert.Equal(t, 1, bytes.Count(toc, []byte("<a ")))
+	assert.NotContains(t, string(toc), "unsupported")
+}
+
+func TestSyncedBlocks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	source := notion.UUID("source")
+
+	synced := func(id notion.UUID, from *notion.UUID) notion.Block {
+		s := &notion.SyncedBlock{}
+		if from != nil {
+			s.SyncedFrom = &notion.SyncedFrom{Type: "block_id", BlockId: from}
+		}
+
+		return notion.Block{Id: id, Type: notion.BlockTypeSyncedBlock, HasChildren: true, SyncedBlock: s}
+	}
+
+	paragraph := func(id notion.UUID, txt string) notion.Block {
+		b := paragraphBlock(&notion.Paragraph{RichText: notion.NewRichTexts(txt)})
+		b.Id = id
+
+		return b
+	}
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			switch id {
+			case "page":
+				return notion.Blocks{synced("copy", &source), synced(source, nil)}, nil
+			case "source":
+				// a copy within the source would repeat the source forever
+				return notion.Blocks{paragraph("p", "Synced"), synced("inner", &source)}, nil
+			default:
+				return nil, fmt.Errorf("the content of %q is in the source", id)
+			}
+		},
+	}
+
+	doc, src, err := NewConverter(cli).Page(ctx, "page")
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 2, doc.ChildCount()) {
+		cp := doc.FirstChild().(*n_ast.SyncedBlock)
+		assert.True(t, cp.Copy)
+		assert.Equal(t, source, cp.SourceID)
+
+		orig := doc.LastChild().(*n_ast.SyncedBlock)
+		assert.False(t, orig.Copy)
+		assert.Equal(t, source, orig.SourceID)
+	}
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, markdown.New().Render(w, src, doc))
+	assert.Equal(t, "Synced\n\nSynced\n", w.String())
+}
+
+func TestEquations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	blocks, err := cli.GetAllBlocks(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	// the paragraph with an inline equation and the equation block
+	g := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) { return cli.GetNotionPage(ctx, id) },
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{blocks[42], blocks[43]}, nil
+		},
+	}
+
+	doc, source, err := NewConverter(g).Page(ctx, fake.PageID)
+	assert.NoError(t, err)
+
+	t.Run("markdown", func(t *testing.T) {
+		t.Parallel()
+
+		md, err := fake.MDCSVExport.ReadFile("md-csv/" + pageRoot("Example Page", "3b4d96f0a5e24e4790ebdbc3f95f936d") + ".md")
+		assert.NoError(t, err)
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+		assert.Contains(t, string(md), w.String())
+		assert.Contains(t, w.String(), "$$\ne^{\\pi i}+1 = 0\n$$\n")
+	})
+
+	t.Run("MathML", func(t *testing.T) {
+		t.Parallel()
+
+		html, err := fake.HTMLExport.ReadFile("html/" + pageRoot("Example Page", fake.PageID) + ".html")
+		assert.NoError(t, err)
+
+		r := goldmark.New(goldmark.WithExtensions(
+			notionhtml.NewExtender(notionhtml.WithMathRenderer(notionhtml.MathML)))).Renderer()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, doc))
+
+		// notion's export contains the MathML that KaTeX generates
+		math := regexp.MustCompile(`<math.*?</math>`)
+		assert.Equal(t, math.FindAllString(string(html), -1), math.FindAllString(w.String(), -1))
+		assert.Contains(t, w.String(), `<figure id="fed8f526-d79c-4429-9ca9-c6aa55e7044b" class="equation"><div class="equation-container"><math`)
+	})
+
+	t.Run("KaTeX", func(t *testing.T) {
+		t.Parallel()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, doc))
+		assert.Contains(t, w.String(), `<span>\(e^{\pi i}+1=0\)</span>`)
+		assert.Contains(t, w.String(), `<div class="equation-container"><span class="katex-display">\[e^{\pi i}+1 = 0\]</span></div></figure>`)
+	})
+}
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	code := func(id notion.UUID, lang notion.CodeLanguage, rts ...notion.RichText) notion.Block {
+		caption := notion.NewRichTexts("A caption.")
+		return notion.Block{Id: id, Type: notion.BlockTypeCode, Code: &notion.Code{
+			Language: lang, RichText: rts, Caption: &caption,
+		}}
+	}
+
+	link := notion.NewRichText("x")
+	href := "https://example.com"
+	link.Href = &href
+
+	cli := &testGetter{
+		page: func(id notion.Id) (*notion.Page, error) {
+			return &notion.Page{Id: notion.UUID(id)}, nil
+		},
+		blocks: func(id notion.Id) (notion.Blocks, error) {
+			return notion.Blocks{
+				code("a", notion.CodeLanguageC1, notion.NewRichText("if ("), link, notion.NewRichText(" < 1) {\n\treturn;\n}")),
+				code("b", "", notion.NewRichText("plain\n")),
+			}, nil
+		},
+	}
+
+	doc, source, err := NewConverter(cli).Page(ctx, "page")
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 2, doc.ChildCount()) {
+		n := doc.FirstChild().(*ast.FencedCodeBlock)
+		assert.Equal(t, "cpp", string(n.Language(source)))
+		assert.Equal(t, 3, n.Lines().Len())
+		line := n.Lines().At(1)
+		assert.Equal(t, "\treturn;\n", string(line.Value(source)))
+
+		assert.Nil(t, doc.LastChild().(*ast.FencedCodeBlock).Info)
+	}
+
+	t.Run("html", func(t *testing.T) {
+		t.Parallel()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, doc))
+		assert.Equal(t, `<pre id="a" class="code"><code>if (x &lt; 1) {`+"\n\treturn;\n}"+`</code></pre>`+
+			`<pre id="b" class="code"><code>plain`+"\n"+`</code></pre>`, w.String())
+	})
+
+	t.Run("highlighter", func(t *testing.T) {
+		t.Parallel()
+
+		r := goldmark.New(goldmark.WithExtensions(notionhtml.NewExtender(notionhtml.WithHighlighter(
+			notionhtml.HighlighterFunc(func(w util.BufWriter, code []byte, language string) error {
+				_, _ = fmt.Fprintf(w, "<span>%s:%d</span>", language, len(code))
+				return nil
+			}))))).Renderer()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, doc))
+		assert.Equal(t, `<pre id="a" class="code"><code><span>cpp:23</span></code></pre>`+
+			`<pre id="b" class="code"><code><span>:6</span></code></pre>`, w.String())
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		t.Parallel()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, markdown.New().Render(w, source, doc))
+		assert.Equal(t, "```cpp\nif (x < 1) {\n\treturn;\n}\n```\n\n```\nplain\n```\n", w.String())
+	})
+}
+
+func TestChromaLexer(t *testing.T) {
+	t.Parallel()
+
+	for lang, want := range map[notion.CodeLanguage]string{
+		notion.CodeLanguageGo:          "go",
+		notion.CodeLanguagePlainText:   "plaintext",
+		notion.CodeLanguageC2:          "csharp",
+		notion.CodeLanguageShell:       "bash",
+		notion.CodeLanguageVisualBasic: "vb.net",
+		"unknown":                      "",
+	} {
+		assert.Equal(t, want, ChromaLexer(lang), lang)
+	}
+}
+
+func TestTransform_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	paragraph := func(id notion.UUID, rts ...notion.RichText) notion.Block {
+		b := paragraphBlock(&notion.Paragraph{RichText: rts})
+		b.Id = id
+		return b
+	}
+
+	newGetter := func(blocks ...notion.Block) notion.Getter {
+		return &testGetter{
+			page: func(id notion.Id) (*notion.Page, error) {
+				return &notion.Page{Id: notion.UUID(id)}, nil
+			},
+			blocks: func(id notion.Id) (notion.Blocks, error) {
+				if id != "page" {
+					return nil, fmt.Errorf("children of %q should not be fetched", id)
+				}
+
+				return blocks, nil
+			},
+		}
+	}
+
+	unsupportedBlock := newGetter(
+		paragraph("a", notion.NewRichText("a")),
+		notion.Block{Id: "new", Type: "foo", HasChildren: true},
+	)
+
+	unsupportedRichText := newGetter(
+		paragraph("b", notion.NewRichText("b "), notion.RichText{Type: "bar", PlainText: "baz"}),
+	)
+
+	render := func(t *testing.T, doc *ast.Document, source []byte) string {
+		t.Helper()
+
+		w := &bytes.Buffer{}
+		assert.NoError(t, r.Render(w, source, doc))
+
+		return w.String()
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := NewConverter(unsupportedBlock).Page(ctx, "page")
+
+		var blockErr *UnsupportedBlockError
+		if assert.True(t, errors.As(err, &blockErr)) {
+			assert.Equal(t, &UnsupportedBlockError{ID: "new", Type: "foo"}, blockErr)
+		}
+
+		_, _, err = NewConverter(unsupportedRichText, WithUnsupportedPolicy(UnsupportedFail)).Page(ctx, "page")
+
+		var richTextErr *UnsupportedRichTextError
+		if assert.True(t, errors.As(err, &richTextErr)) {
+			assert.Equal(t, &UnsupportedRichTextError{BlockID: "b", Type: "bar"}, richTextErr)
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		t.Parallel()
+
+		doc, source, err := NewConverter(unsupportedBlock, WithUnsupportedPolicy(UnsupportedSkip)).Page(ctx, "page")
+		assert.NoError(t, err)
+		assert.Equal(t, `<p id="a" class="">a</p>`, render(t, doc, source))
+
+		doc, source, err = NewConverter(unsupportedRichText, WithUnsupportedPolicy(UnsupportedSkip)).Page(ctx, "page")
+		assert.NoError(t, err)
+		assert.Equal(t, `<p id="b" class="">b </p>`, render(t, doc, source))
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		t.Parallel()
+
+		doc, source, err := NewConverter(unsupportedBlock, WithUnsupportedPolicy(UnsupportedPlaceholder)).Page(ctx, "page")
+		assert.NoError(t, err)
+		assert.Equal(t, `<p id="a" class="">a</p><!-- unsupported block type foo -->`, render(t, doc, source))
+
+		doc, source, err = NewConverter(unsupportedRichText, WithUnsupportedPolicy(UnsupportedPlaceholder)).Page(ctx, "page")
+		assert.NoError(t, err)
+		assert.Equal(t, `<p id="b" class="">b baz</p>`, render(t, doc, source))
+	})
 }
 
 type testRoundtripper struct {
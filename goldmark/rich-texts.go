@@ -1,8 +1,6 @@
 package goldmark
 
 import (
-	"fmt"
-
 	n_ast "github.com/faetools/notion-to-goldmark/ast"
 	"github.com/samber/lo"
 
@@ -58,9 +56,14 @@ func newAnnotationWrapper(t notion.RichText) *annotationWrapper {
 	case notion.RichTextTypeEquation:
 		wr.node = toNodeEquation(t.Equation)
 	case notion.RichTextTypeMention:
-		wr.node = &n_ast.Mention{Content: t.Mention}
+		n := &n_ast.Mention{Content: t.Mention}
+		n.AppendChild(n, newString(t.PlainText))
+		wr.node = n
 	default:
-		panic(fmt.Sprintf("invalid RichText of type %q", t.Type))
+		// the policy for unsupported content is applied once the block is converted
+		n := n_ast.NewUnsupported(false, string(t.Type))
+		n.AppendChild(n, newString(t.PlainText))
+		wr.node = n
 	}
 
 	return wr
@@ -49,8 +49,12 @@ const t1, t2, t3 = "foo", "bar", "blub"
 func TestRichTexts(t *testing.T) {
 	t.Parallel()
 
-	assert.PanicsWithValue(t, `invalid RichText of type ""`,
-		func() { _ = newAnnotationWrapper(notion.RichText{}) })
+	w := newAnnotationWrapper(notion.RichText{Type: "foo", PlainText: "bar"})
+	if u, ok := w.node.(*n_ast.Unsupported); assert.True(t, ok) {
+		assert.Equal(t, "foo", u.NotionType)
+		assert.False(t, u.Block)
+		assert.Equal(t, "bar", string(u.Text(nil)))
+	}
 
 	for _, tt := range []struct {
 		name string
@@ -0,0 +1,58 @@
+package goldmark
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// segment appends s to the source of the page and returns its segment.
+func (p *pageCollector) segment(s string) text.Segment {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := len(p.source)
+	p.source = append(p.source, s...)
+
+	return text.NewSegment(start, len(p.source))
+}
+
+// lines appends s to the source of the page and returns the segments of its lines.
+// Every line but the last one ends with its newline.
+func (p *pageCollector) lines(s string) *text.Segments {
+	seg := p.segment(s)
+
+	lines := text.NewSegments()
+	for start := seg.Start; start < seg.Stop; {
+		end := seg.Stop
+		if i := strings.IndexByte(s[start-seg.Start:], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+
+		lines.Append(text.NewSegment(start, end))
+		start = end
+	}
+
+	return lines
+}
+
+// backStrings replaces the strings in n with texts that are backed by the source.
+func (p *pageCollector) backStrings(n ast.Node) {
+	strs := []*ast.String{}
+
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if s, ok := n.(*ast.String); ok && entering {
+			strs = append(strs, s)
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, s := range strs {
+		t := ast.NewTextSegment(p.segment(string(s.Value)))
+		t.SetRaw(s.IsRaw())
+
+		s.Parent().ReplaceChild(s.Parent(), s, t)
+	}
+}
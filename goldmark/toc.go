@@ -0,0 +1,206 @@
+package goldmark
+
+import (
+	"strconv"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/yuin/goldmark/ast"
+)
+
+func toNodeTableOfContents(id notion.UUID, toc *notion.TableOfContents) ast.Node {
+	n := &n_ast.TableOfContents{}
+	n.SetAttributeString(attrID, []byte(id))
+	setClasses(n, toc.Color, classTableOfContents)
+
+	return n
+}
+
+func (p *pageCollector) toNodeHeading(level int, id notion.UUID, h *notion.Paragraph) ast.Node {
+	// remember the text for the table of contents
+	p.mu.Lock()
+	if p.headings == nil {
+		p.headings = map[notion.UUID]notion.RichTexts{}
+	}
+	p.headings[id] = h.RichText
+	p.mu.Unlock()
+
+	return toNodeParagraph(ast.NewHeading(level), id, h)
+}
+
+// addTablesOfContents fills the tables of contents with links to the headings.
+// It needs to be called once all blocks have been converted.
+func (p *pageCollector) addTablesOfContents(nodes []ast.Node) error {
+	var (
+		headings []*ast.Heading
+		tocs     []*n_ast.TableOfContents
+	)
+
+	for _, n := range nodes {
+		_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+
+			switch n := n.(type) {
+			case *ast.Heading:
+				// e.g. the titles of child databases are no heading blocks
+				if p.isHeadingBlock(n) && !isNested(n) {
+					headings = append(headings, n)
+				}
+			case *n_ast.TableOfContents:
+				tocs = append(tocs, n)
+			}
+
+			return ast.WalkContinue, nil
+		})
+	}
+
+	for _, toc := range tocs {
+		list, err := p.toNodeHeadings(headings)
+		if err != nil {
+			return err
+		}
+
+		toc.AppendChild(toc, list)
+	}
+
+	return nil
+}
+
+// isNested returns whether the node is within the children of another block.
+// Like Notion, tables of contents only list the headings at the top of the page,
+// which includes those in columns and synced blocks.
+func isNested(n ast.Node) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == n_ast.KindBlockChildren && p.Parent() != nil &&
+			p.Parent().Kind() != n_ast.KindSyncedBlock {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHeadingBlock returns whether the heading was converted from a heading block.
+func (p *pageCollector) isHeadingBlock(h *ast.Heading) bool {
+	id, ok := h.AttributeString(attrID)
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, ok = p.headings[notion.UUID(id.([]byte))]
+
+	return ok
+}
+
+// toNodeHeadings returns the headings as a nested list of links.
+func (p *pageCollector) toNodeHeadings(headings []*ast.Heading) (*ast.List, error) {
+	// the largest headings are not indented
+	minLevel := 0
+	for _, h := range headings {
+		if minLevel == 0 || h.Level < minLevel {
+			minLevel = h.Level
+		}
+	}
+
+	type nested struct {
+		indent int
+		list   *ast.List
+	}
+
+	root := ast.NewList('-')
+	stack := []nested{{0, root}}
+
+	for _, h := range headings {
+		indent := h.Level - minLevel
+
+		for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		top := stack[len(stack)-1]
+
+		if last := top.list.LastChild(); indent > top.indent && last != nil {
+			top = nested{indent, ast.NewList('-')}
+			last.AppendChild(last, top.list)
+			stack = append(stack, top)
+		}
+
+		item, err := p.toNodeHeadingLink(h, indent)
+		if err != nil {
+			return nil, err
+		}
+
+		top.list.AppendChild(top.list, item)
+	}
+
+	return root, nil
+}
+
+// toNodeHeadingLink returns the list item linking to the heading.
+func (p *pageCollector) toNodeHeadingLink(h *ast.Heading, indent int) (ast.Node, error) {
+	id, _ := h.AttributeString(attrID)
+	blockID := notion.UUID(id.([]byte))
+
+	link := ast.NewLink()
+	link.Destination = append([]byte{'#'}, id.([]byte)...)
+
+	p.mu.Lock()
+	appendRichTexts(link, p.headings[blockID])
+	p.mu.Unlock()
+
+	// like the heading, the link leaves out what is not supported
+	if _, err := p.checkUnsupported(blockID, link); err != nil {
+		return nil, err
+	}
+
+	unwrapLinks(link)
+
+	text := ast.NewTextBlock()
+	setClasses(text, "", classTableOfContentsItem,
+		[]byte(classTableOfContentsIndentPrefix+strconv.Itoa(indent)))
+	text.AppendChild(text, link)
+
+	item := ast.NewListItem(0)
+	item.AppendChild(item, text)
+
+	return item, nil
+}
+
+// unwrapLinks replaces the links and linked mentions within n with their text,
+// since links cannot be nested.
+func unwrapLinks(n ast.Node) {
+	var links []ast.Node
+
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || c == n {
+			return ast.WalkContinue, nil
+		}
+
+		switch c := c.(type) {
+		case *ast.Link:
+			links = append(links, c)
+		case *n_ast.Mention:
+			switch c.Content.Type {
+			case notion.MentionTypePage, notion.MentionTypeDatabase, notion.MentionTypeLinkPreview:
+				links = append(links, c)
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, l := range links {
+		parent := l.Parent()
+
+		for c := l.FirstChild(); c != nil; c = l.FirstChild() {
+			parent.InsertBefore(parent, l, c)
+		}
+
+		parent.RemoveChild(parent, l)
+	}
+}
@@ -0,0 +1,77 @@
+package goldmark
+
+import (
+	"fmt"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/yuin/goldmark/ast"
+)
+
+// UnsupportedPolicy defines what happens with blocks and rich texts that cannot be converted.
+type UnsupportedPolicy int
+
+const (
+	// UnsupportedFail returns an error for anything unsupported.
+	UnsupportedFail UnsupportedPolicy = iota
+	// UnsupportedSkip leaves out anything unsupported.
+	UnsupportedSkip
+	// UnsupportedPlaceholder converts anything unsupported into an n_ast.Unsupported node.
+	UnsupportedPlaceholder
+)
+
+// UnsupportedBlockError is returned if a block has a type that is not supported.
+type UnsupportedBlockError struct {
+	ID   notion.UUID
+	Type notion.BlockType
+}
+
+func (e *UnsupportedBlockError) Error() string {
+	return fmt.Sprintf("block %s has unsupported type %q", e.ID, e.Type)
+}
+
+// UnsupportedRichTextError is returned if a rich text has a type that is not supported.
+type UnsupportedRichTextError struct {
+	// BlockID is the ID of the block containing the rich text.
+	BlockID notion.UUID
+	Type    notion.RichTextType
+}
+
+func (e *UnsupportedRichTextError) Error() string {
+	return fmt.Sprintf("block %s contains rich text of unsupported type %q", e.BlockID, e.Type)
+}
+
+// checkUnsupported applies the policy to all unsupported nodes in the node of the block.
+// It returns nil if the node itself is unsupported and should be skipped.
+func (p *pageCollector) checkUnsupported(id notion.UUID, n ast.Node) (ast.Node, error) {
+	var unsupported []*n_ast.Unsupported
+
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if u, ok := n.(*n_ast.Unsupported); ok && entering {
+			unsupported = append(unsupported, u)
+			return ast.WalkSkipChildren, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, u := range unsupported {
+		switch p.unsupported {
+		case UnsupportedSkip:
+			if ast.Node(u) == n {
+				return nil, nil
+			}
+
+			u.Parent().RemoveChild(u.Parent(), u)
+		case UnsupportedPlaceholder:
+		default:
+			if u.Block {
+				return nil, &UnsupportedBlockError{ID: id, Type: notion.BlockType(u.NotionType)}
+			}
+
+			return nil, &UnsupportedRichTextError{BlockID: id, Type: notion.RichTextType(u.NotionType)}
+		}
+	}
+
+	return n, nil
+}
@@ -2,7 +2,7 @@ package goldmark
 
 import (
 	"bytes"
-	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/faetools/go-notion/pkg/notion"
@@ -13,6 +13,8 @@ import (
 const (
 	blockColorPrefix = "block-color-"
 
+	classTableOfContentsIndentPrefix = "table_of_contents-indent-"
+
 	attrClass   = "class"
 	attrID      = "id"
 	attrType    = "type"
@@ -21,6 +23,7 @@ const (
 	attrStyle   = "style"
 	attrD       = "d"
 	attrPoints  = "points"
+	attrScope   = "scope"
 )
 
 var (
@@ -40,9 +43,22 @@ var (
 	classLinkToPage            = []byte("link-to-page")
 	classCollectionContent     = []byte("collection-content")
 	classCollectionTitle       = []byte("collection-title")
+	classCardProperty          = []byte("collection-card-property")
 	classIcon                  = []byte("icon")
 	classPropertyIcon          = []byte("property-icon")
 	classURLValue              = []byte("url-value")
+	classSimpleTable           = []byte("simple-table")
+	classSimpleTableHeader     = []byte("simple-table-header")
+	classSimpleTableHeaderCell = []byte("simple-table-header-color simple-table-header")
+	classColumnList            = []byte("column-list")
+	classColumn                = []byte("column")
+	classImage                 = []byte("image")
+	classEquation              = []byte("equation")
+	classPageTitle             = []byte("page-title")
+	classTableOfContents       = []byte("table_of_contents")
+	classTableOfContentsItem   = []byte("table_of_contents-item")
+
+	scopeRow = []byte("row")
 
 	viewBoxStandard = []byte("0 0 14 14")
 	viewBoxStatus   = []byte("0 0 16 16")
@@ -70,11 +86,12 @@ func appendRichTexts(n ast.Node, txts notion.RichTexts) {
 	}
 }
 
+// isNotionHosted returns whether the URL points to a file uploaded to Notion.
+func isNotionHosted(u *url.URL) bool {
+	return u.Host == "s3.us-west-2.amazonaws.com" &&
+		strings.HasPrefix(u.Path, "/secure.notion-static.com/")
+}
+
 func getDir(name string, id notion.UUID) string {
-	return string(
-		util.URLEscape(
-			[]byte(
-				fmt.Sprintf("%s %s", name, strings.ReplaceAll(string(id), "-", "")),
-			),
-			true))
+	return string(util.URLEscape([]byte(FileName(name, id)), true))
 }
@@ -0,0 +1,307 @@
+package goldmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/samber/lo"
+)
+
+// DatabaseEntriesGetter is implemented by notion.Getters that can filter and sort
+// the entries of a database on Notion's side, like notion.Client.
+type DatabaseEntriesGetter interface {
+	GetDatabaseEntries(ctx context.Context, id notion.Id, filter *notion.Filter, sorts *notion.Sorts) (notion.Pages, error)
+}
+
+// DatabaseView describes which entries and properties of a database are shown
+// and in which order, like a view of the database in Notion.
+//
+// The Notion API does not return the views of a database, so they need to be passed to the
+// converter with WithDatabaseView.
+type DatabaseView struct {
+	// Filter filters the entries. It needs a notion.Getter that implements DatabaseEntriesGetter.
+	Filter *notion.Filter
+	// Sorts sort the entries. If the notion.Getter does not implement DatabaseEntriesGetter,
+	// the entries are sorted by the converter instead.
+	// By default, the entries are sorted by their title.
+	//
+	// Since notion.Sort cannot sort by timestamp, the entries are sorted by when they were
+	// created or last edited if the property is "created_time" or "last_edited_time"
+	// and the database has no such property. They are always sorted by the converter.
+	Sorts notion.Sorts
+	// Properties are the names of the visible properties in the order they are shown in.
+	// By default, tables show all properties, the title first and the rest sorted by name,
+	// and cards only show the title.
+	Properties []string
+
+	// Layout is how the entries are shown. By default, they are shown in a table
+	// without a n_ast.DatabaseView around it.
+	Layout n_ast.DatabaseLayout
+	// GroupBy is the name of the select or status property the columns of a board are grouped by.
+	GroupBy string
+	// CalendarBy is the name of the date property the entries of a calendar are shown by.
+	CalendarBy string
+}
+
+// errNoDatabaseEntriesGetter is returned by limitGetter if its notion.Getter
+// does not implement DatabaseEntriesGetter.
+var errNoDatabaseEntriesGetter = errors.New("notion.Getter does not implement DatabaseEntriesGetter")
+
+// getEntries returns the entries of the database in the view.
+func (p *pageCollector) getEntries(db *notion.Database, v DatabaseView) (notion.Pages, error) {
+	id := notion.Id(db.Id)
+
+	byTimestamp := lo.SomeBy(v.Sorts, func(s notion.Sort) bool { return isTimestampSort(s, db.Properties) })
+
+	if v.Filter != nil || (len(v.Sorts) > 0 && !byTimestamp) {
+		var sorts *notion.Sorts
+		if len(v.Sorts) > 0 && !byTimestamp {
+			sorts = &v.Sorts
+		}
+
+		err := errNoDatabaseEntriesGetter
+
+		if cli, ok := p.cli.(DatabaseEntriesGetter); ok {
+			var entries notion.Pages
+			if entries, err = cli.GetDatabaseEntries(p.ctx, id, v.Filter, sorts); err == nil {
+				if byTimestamp {
+					// the entries are shared with the notion.Getter
+					entries = append(notion.Pages{}, entries...)
+					sortEntries(entries, v.Sorts, db.Properties)
+				}
+
+				return entries, nil
+			}
+		}
+
+		switch {
+		case !errors.Is(err, errNoDatabaseEntriesGetter):
+			return nil, err
+		case v.Filter != nil:
+			return nil, fmt.Errorf("filtering the entries of database %s: %w", id, err)
+		}
+	}
+
+	entries, err := p.cli.GetAllDatabaseEntries(p.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the entries are shared with the notion.Getter
+	entries = append(notion.Pages{}, entries...)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		t1 := entries[i].Title()
+		t2 := entries[j].Title()
+
+		if t1 == "" {
+			return false
+		}
+
+		return t2 == "" || t1 < t2
+	})
+
+	if len(v.Sorts) > 0 {
+		sortEntries(entries, v.Sorts, db.Properties)
+	}
+
+	return entries, nil
+}
+
+// propertyKeys returns the names of the properties of the database that are shown in the view.
+func propertyKeys(db *notion.Database, v DatabaseView) ([]string, error) {
+	if v.Properties != nil {
+		for _, name := range v.Properties {
+			if _, ok := db.Properties[name]; !ok {
+				return nil, fmt.Errorf("database %s has no property %q", db.Id, name)
+			}
+		}
+
+		return v.Properties, nil
+	}
+
+	keys := lo.Keys(db.Properties)
+
+	// the title first, the rest are sorted alphabetically
+	sort.SliceStable(keys, func(i, j int) bool {
+		if db.Properties[keys[j]].Type == notion.PropertyTypeTitle {
+			return false
+		}
+
+		return db.Properties[keys[i]].Type == notion.PropertyTypeTitle || keys[i] < keys[j]
+	})
+
+	return keys, nil
+}
+
+// sortEntries sorts the entries like Notion does:
+// Empty values come last, options are sorted in the order of the database schema.
+func sortEntries(entries notion.Pages, sorts notion.Sorts, props notion.PropertyMetaMap) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		for _, s := range sorts {
+			a, aOK := entrySortValue(entries[i], s, props)
+			b, bOK := entrySortValue(entries[j], s, props)
+
+			switch {
+			case !aOK && !bOK:
+				continue
+			case !aOK || !bOK:
+				return aOK
+			}
+
+			c := compareSortValues(a, b)
+			if c == 0 {
+				continue
+			}
+
+			if s.Direction == notion.SortDirectionDescending {
+				return c > 0
+			}
+
+			return c < 0
+		}
+
+		return false
+	})
+}
+
+// The timestamps that entries can be sorted by instead of a property.
+const (
+	timestampCreatedTime    = "created_time"
+	timestampLastEditedTime = "last_edited_time"
+)
+
+// isTimestampSort reports whether the sort is by when the entries were created or last edited.
+func isTimestampSort(s notion.Sort, props notion.PropertyMetaMap) bool {
+	if _, ok := props[s.Property]; ok {
+		return false
+	}
+
+	return s.Property == timestampCreatedTime || s.Property == timestampLastEditedTime
+}
+
+// entrySortValue returns the value the entry is sorted by, if it is not empty.
+func entrySortValue(entry notion.Page, s notion.Sort, props notion.PropertyMetaMap) (interface{}, bool) {
+	if !isTimestampSort(s, props) {
+		return sortValue(entry.Properties[s.Property], props[s.Property])
+	}
+
+	if s.Property == timestampLastEditedTime {
+		return entry.LastEditedTime, true
+	}
+
+	if entry.CreatedTime != nil {
+		return *entry.CreatedTime, true
+	}
+
+	return nil, false
+}
+
+// sortValue returns the value the property is sorted by, if it is not empty.
+// It is a string, float64 or time.Time.
+func sortValue(prop notion.PropertyValue, meta notion.PropertyMeta) (interface{}, bool) {
+	switch prop.Type {
+	case notion.PropertyTypeTitle, notion.PropertyTypeRichText,
+		notion.PropertyTypeUrl, notion.PropertyTypeEmail, notion.PropertyTypePhoneNumber:
+		if s, ok := frontMatterValue(prop).(string); ok {
+			return strings.ToLower(s), true
+		}
+	case notion.PropertyTypeNumber:
+		if prop.Number != nil {
+			return float64(*prop.Number), true
+		}
+	case notion.PropertyTypeCheckbox:
+		// unchecked comes first
+		if prop.GetCheckbox() {
+			return 1.0, true
+		}
+
+		return 0.0, true
+	case notion.PropertyTypeSelect:
+		if prop.Select != nil {
+			return optionIndex(meta.Select, prop.Select.Name), true
+		}
+	case notion.PropertyTypeStatus:
+		if prop.Status != nil {
+			return optionIndex(statusOptions(meta), prop.Status.Name), true
+		}
+	case notion.PropertyTypeDate:
+		if prop.Date != nil {
+			return prop.Date.Start, true
+		}
+	case notion.PropertyTypeCreatedTime:
+		if prop.CreatedTime != nil {
+			return *prop.CreatedTime, true
+		}
+	}
+
+	return nil, false
+}
+
+// optionIndex returns the position of the option in the schema.
+func optionIndex(options *notion.SelectValuesWrapper, name string) float64 {
+	if options != nil {
+		for i, o := range options.Options {
+			if o.Name == name {
+				return float64(i)
+			}
+		}
+	}
+
+	return -1
+}
+
+// statusOptions returns the options of a status property in the order of the database schema.
+// go-notion does not define the configuration of status properties, so it is decoded here.
+func statusOptions(meta notion.PropertyMeta) *notion.SelectValuesWrapper {
+	if meta.Status == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(meta.Status)
+	if err != nil {
+		return nil
+	}
+
+	options := &notion.SelectValuesWrapper{}
+	if err := json.Unmarshal(b, options); err != nil {
+		return nil
+	}
+
+	return options
+}
+
+// compareSortValues compares two values returned by sortValue.
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case float64:
+		b := b.(float64)
+
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case time.Time:
+		b := b.(time.Time)
+
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,245 @@
+package goldmark_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	. "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+const (
+	databaseID = "7a3c647e-4c1e-4c27-bf1d-cfb0105e55ce"
+
+	entry1   = "20c5eed2-59ea-476e-af69-da0ae68a084d"
+	entry2   = "5f17fb6b-2318-4521-b83d-c0f5438abd0b"
+	entry3   = "45d81ac0-bf71-4a31-b096-941dd050f3e1"
+	untitled = "c85a6329-44c0-4a8f-98d4-0ddd0e756b10"
+)
+
+// entriesGetter filters and sorts database entries like notion.Client.
+type entriesGetter struct {
+	*testGetter
+
+	filter *notion.Filter
+	sorts  *notion.Sorts
+}
+
+// GetDatabaseEntries implements DatabaseEntriesGetter.
+func (g *entriesGetter) GetDatabaseEntries(_ context.Context, id notion.Id, filter *notion.Filter, sorts *notion.Sorts) (notion.Pages, error) {
+	g.filter, g.sorts = filter, sorts
+
+	entries, err := g.databaseEntries(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// pretend that only the first entry matched
+	return entries[:1], nil
+}
+
+func fakeGetter(t *testing.T) *testGetter {
+	t.Helper()
+
+	ctx := context.Background()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	return &testGetter{
+		page:            func(id notion.Id) (*notion.Page, error) { return cli.GetNotionPage(ctx, id) },
+		blocks:          func(id notion.Id) (notion.Blocks, error) { return cli.GetAllBlocks(ctx, id) },
+		database:        func(id notion.Id) (*notion.Database, error) { return cli.GetNotionDatabase(ctx, id) },
+		databaseEntries: func(id notion.Id) (notion.Pages, error) { return cli.GetAllDatabaseEntries(ctx, id) },
+	}
+}
+
+// statusGetter returns a fakeGetter whose status property has the options "Not started", "no idea" and "Done",
+// which go-notion's fake database does not define.
+func statusGetter(t *testing.T) *testGetter {
+	t.Helper()
+
+	g := fakeGetter(t)
+	database := g.database
+	g.database = func(id notion.Id) (*notion.Database, error) {
+		db, err := database(id)
+		if err != nil {
+			return nil, err
+		}
+
+		status := db.Properties["Status"]
+		status.Status = &map[string]interface{}{"options": []map[string]string{
+			{"name": "Not started", "color": "default"},
+			{"name": "no idea", "color": "gray"},
+			{"name": "Done", "color": "green"},
+		}}
+		db.Properties["Status"] = status
+
+		return db, nil
+	}
+
+	return g
+}
+
+func convertDatabase(t *testing.T, cli notion.Getter, v DatabaseView) (*extast.Table, error) {
+	t.Helper()
+
+	doc, _, err := NewConverter(cli, WithDatabaseView(databaseID, v)).Database(context.Background(), databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	table, _ := doc.FirstChild().(*extast.Table)
+	assert.NotNil(t, table)
+
+	return table, nil
+}
+
+// rowIDs returns the IDs of the entries in the rows of the table.
+func rowIDs(table *extast.Table) []string {
+	ids := []string{}
+
+	for row := table.FirstChild().NextSibling(); row != nil; row = row.NextSibling() {
+		id, _ := row.AttributeString("id")
+		ids = append(ids, string(id.([]byte)))
+	}
+
+	return ids
+}
+
+// columns returns the names of the properties in the header of the table.
+func columns(table *extast.Table) []string {
+	names := []string{}
+
+	for cell := table.FirstChild().FirstChild(); cell != nil; cell = cell.NextSibling() {
+		names = append(names, string(cell.LastChild().(*ast.String).Value))
+	}
+
+	return names
+}
+
+func TestDatabaseView(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		table, err := convertDatabase(t, fakeGetter(t), DatabaseView{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{entry1, entry2, entry3, untitled}, rowIDs(table))
+
+		cols := columns(table)
+		assert.Len(t, cols, 30)
+		assert.Equal(t, []string{"Name", "A number", "Equal relation"}, cols[:3])
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		t.Parallel()
+
+		table, err := convertDatabase(t, fakeGetter(t), DatabaseView{Properties: []string{"Status", "Name", "A number"}})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"Status", "Name", "A number"}, columns(table))
+
+		row := table.FirstChild().NextSibling()
+		assert.Equal(t, 3, row.ChildCount())
+
+		_, err = convertDatabase(t, fakeGetter(t), DatabaseView{Properties: []string{"Unknown"}})
+		assert.EqualError(t, err, `database `+databaseID+` has no property "Unknown"`)
+	})
+
+	t.Run("sorted by the converter", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tt := range map[string]struct {
+			sorts notion.Sorts
+			want  []string
+		}{
+			"number descending": {
+				notion.Sorts{{Property: "A number", Direction: notion.SortDirectionDescending}},
+				[]string{entry3, entry2, entry1, untitled},
+			},
+			"select in the order of the options": {
+				notion.Sorts{{Property: "select", Direction: notion.SortDirectionAscending}},
+				[]string{entry2, entry1, entry3, untitled},
+			},
+			"date descending": {
+				notion.Sorts{{Property: "some date", Direction: notion.SortDirectionDescending}},
+				[]string{entry2, entry1, entry3, untitled},
+			},
+			"status in the order of the options": {
+				notion.Sorts{{Property: "Status", Direction: notion.SortDirectionAscending}},
+				[]string{entry2, entry3, entry1, untitled},
+			},
+			"last edited time descending": {
+				notion.Sorts{{Property: "last_edited_time", Direction: notion.SortDirectionDescending}},
+				[]string{untitled, entry2, entry1, entry3},
+			},
+			"created time": {
+				notion.Sorts{{Property: "created_time", Direction: notion.SortDirectionAscending}},
+				[]string{entry1, entry2, entry3, untitled},
+			},
+			"several properties": {
+				notion.Sorts{
+					{Property: "My checkbox", Direction: notion.SortDirectionAscending},
+					{Property: "Name", Direction: notion.SortDirectionDescending},
+				},
+				[]string{entry3, entry2, entry1, untitled},
+			},
+		} {
+			table, err := convertDatabase(t, statusGetter(t), DatabaseView{Sorts: tt.sorts})
+			if assert.NoError(t, err, name) {
+				assert.Equal(t, tt.want, rowIDs(table), name)
+			}
+		}
+	})
+
+	t.Run("filter without DatabaseEntriesGetter", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := convertDatabase(t, fakeGetter(t), DatabaseView{Filter: &notion.Filter{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("filtered and sorted by Notion", func(t *testing.T) {
+		t.Parallel()
+
+		property, contains := "Name", "entry"
+		filter := &notion.Filter{Property: &property, Contains: &contains}
+		sorts := notion.Sorts{{Property: "A number", Direction: notion.SortDirectionDescending}}
+
+		g := &entriesGetter{testGetter: fakeGetter(t)}
+
+		table, err := convertDatabase(t, g, DatabaseView{Filter: filter, Sorts: sorts})
+		assert.NoError(t, err)
+
+		assert.Equal(t, filter, g.filter)
+		assert.Equal(t, &sorts, g.sorts)
+
+		// the entries are shown as returned by Notion
+		assert.Equal(t, []string{untitled}, rowIDs(table))
+	})
+
+	t.Run("filtered by Notion and sorted by timestamp", func(t *testing.T) {
+		t.Parallel()
+
+		property, contains := "Name", "entry"
+		filter := &notion.Filter{Property: &property, Contains: &contains}
+		sorts := notion.Sorts{{Property: "last_edited_time", Direction: notion.SortDirectionDescending}}
+
+		g := &entriesGetter{testGetter: fakeGetter(t)}
+
+		table, err := convertDatabase(t, g, DatabaseView{Filter: filter, Sorts: sorts})
+		assert.NoError(t, err)
+
+		// Notion cannot be asked to sort by timestamp with notion.Sort
+		assert.Equal(t, filter, g.filter)
+		assert.Nil(t, g.sorts)
+		assert.Equal(t, []string{untitled}, rowIDs(table))
+	})
+}
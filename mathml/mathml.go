@@ -0,0 +1,845 @@
+// Package mathml converts LaTeX math expressions to MathML,
+// which browsers display without any JavaScript.
+//
+// It supports the parts of LaTeX that are commonly used in Notion equations:
+// scripts, fractions, roots, Greek letters and symbols, functions, accents, fonts,
+// text, spacing, delimiters with \left and \right as well as matrices and cases.
+// The markup follows the MathML KaTeX generates.
+package mathml
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Convert returns the MathML math element of the LaTeX expression.
+// Equations in display mode are displayed as blocks.
+func Convert(tex string, display bool) (string, error) {
+	p := &parser{src: tex, display: display}
+
+	nodes, err := p.parseLines()
+	if err != nil {
+		return "", err
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+
+	if display {
+		b.WriteString(` display="block"`)
+	}
+
+	b.WriteString("><semantics>")
+
+	if len(nodes) == 1 && (nodes[0].tag == "mrow" || nodes[0].tag == "mtable") {
+		nodes[0].write(b)
+	} else {
+		row(nodes...).write(b)
+	}
+
+	b.WriteString(`<annotation encoding="application/x-tex">`)
+	b.WriteString(html.EscapeString(tex))
+	b.WriteString("</annotation></semantics></math>")
+
+	return b.String(), nil
+}
+
+// node is a MathML element.
+type node struct {
+	tag      string
+	attrs    [][2]string
+	text     string
+	children []*node
+
+	// limits is set for big operators like sums,
+	// whose scripts are placed below and above them in display mode
+	limits bool
+	// apply is set for functions like sin,
+	// which are followed by an invisible function application
+	apply bool
+}
+
+func token(tag, text string) *node { return &node{tag: tag, text: text} }
+
+func row(children ...*node) *node { return &node{tag: "mrow", children: children} }
+
+// group returns the nodes as a single node.
+func group(nodes []*node) *node {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+
+	return row(nodes...)
+}
+
+func (n *node) attr(key, val string) *node {
+	n.attrs = append(n.attrs, [2]string{key, val})
+	return n
+}
+
+func (n *node) isToken() bool {
+	switch n.tag {
+	case "mi", "mn", "mo", "mtext", "mspace":
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *node) write(b *strings.Builder) {
+	b.WriteString("<" + n.tag)
+
+	for _, a := range n.attrs {
+		b.WriteString(" " + a[0] + `="` + html.EscapeString(a[1]) + `"`)
+	}
+
+	if n.tag == "mspace" {
+		b.WriteString("/>")
+		return
+	}
+
+	b.WriteString(">")
+	b.WriteString(html.EscapeString(n.text))
+
+	for _, c := range n.children {
+		c.write(b)
+	}
+
+	b.WriteString("</" + n.tag + ">")
+}
+
+// setVariant sets the font of all tokens within the node.
+func (n *node) setVariant(variant string) {
+	if n.isToken() && n.tag != "mspace" {
+		n.attr("mathvariant", variant)
+		return
+	}
+
+	for _, c := range n.children {
+		c.setVariant(variant)
+	}
+}
+
+type parser struct {
+	src     string
+	pos     int
+	display bool
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("position %d: "+format, append([]any{p.pos}, args...)...)
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() rune {
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return r
+}
+
+func (p *parser) next() rune {
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+
+	return r
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// peekCommand returns the name of the command at the current position, if any.
+func (p *parser) peekCommand() string {
+	if p.eof() || p.src[p.pos] != '\\' || p.pos+1 >= len(p.src) {
+		return ""
+	}
+
+	end := p.pos + 1
+	for end < len(p.src) && isLetter(p.src[end]) {
+		end++
+	}
+
+	if end == p.pos+1 {
+		// a command made of a single non-letter
+		_, size := utf8.DecodeRuneInString(p.src[end:])
+		end += size
+	}
+
+	return p.src[p.pos+1 : end]
+}
+
+func (p *parser) command() string {
+	name := p.peekCommand()
+	p.pos += len(name) + 1
+
+	return name
+}
+
+func isLetter(c byte) bool { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' }
+
+// atEnd reports whether the current list of nodes ends here.
+func (p *parser) atEnd() bool {
+	p.skipSpace()
+
+	if p.eof() {
+		return true
+	}
+
+	switch p.src[p.pos] {
+	case '}', '&':
+		return true
+	}
+
+	switch p.peekCommand() {
+	case "\\", "right", "end":
+		return true
+	}
+
+	return false
+}
+
+// parseLines parses the whole expression, which may consist of several lines.
+func (p *parser) parseLines() ([]*node, error) {
+	var nodes []*node
+
+	for {
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, list...)
+
+		if p.eof() {
+			return nodes, nil
+		}
+
+		if p.peekCommand() != "\\" {
+			return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1+len(p.peekCommand())])
+		}
+
+		p.command()
+		nodes = append(nodes, (&node{tag: "mspace"}).attr("linebreak", "newline"))
+	}
+}
+
+// parseList parses nodes until the end of the current group.
+func (p *parser) parseList() ([]*node, error) {
+	var nodes []*node
+
+	for !p.atEnd() {
+		ns, err := p.parseScripts()
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, ns...)
+	}
+
+	return nodes, nil
+}
+
+// parseGroup parses a group in braces.
+func (p *parser) parseGroup() ([]*node, error) {
+	p.skipSpace()
+
+	if p.eof() || p.src[p.pos] != '{' {
+		return nil, p.errorf("expected {")
+	}
+
+	p.pos++
+
+	nodes, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.eof() || p.src[p.pos] != '}' {
+		return nil, p.errorf("expected }")
+	}
+
+	p.pos++
+
+	return nodes, nil
+}
+
+// parseRaw returns the content of a group in braces without parsing it.
+func (p *parser) parseRaw() (string, error) {
+	p.skipSpace()
+
+	if p.eof() || p.src[p.pos] != '{' {
+		return "", p.errorf("expected {")
+	}
+
+	start := p.pos + 1
+
+	for depth := 0; !p.eof(); {
+		switch p.next() {
+		case '\\':
+			if !p.eof() {
+				p.next()
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return p.src[start : p.pos-1], nil
+			}
+		}
+	}
+
+	return "", p.errorf("expected }")
+}
+
+// parseArg parses the argument of a command or script,
+// which is either a group or a single symbol.
+func (p *parser) parseArg() (*node, error) {
+	p.skipSpace()
+
+	if p.eof() {
+		return nil, p.errorf("missing argument")
+	}
+
+	if p.src[p.pos] == '{' {
+		nodes, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+
+		return group(nodes), nil
+	}
+
+	if p.atEnd() {
+		return nil, p.errorf("missing argument")
+	}
+
+	// only a single digit, e.g. x^23 is x²3
+	if c := p.src[p.pos]; '0' <= c && c <= '9' {
+		p.pos++
+		return token("mn", string(c)), nil
+	}
+
+	nodes, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+
+	return group(nodes), nil
+}
+
+// parseScripts parses an atom with its subscript and superscript, if any.
+func (p *parser) parseScripts() ([]*node, error) {
+	p.skipSpace()
+
+	var (
+		base     *node
+		sub, sup *node
+		primes   string
+	)
+
+	// scripts without a base
+	if c := p.src[p.pos]; c != '^' && c != '_' {
+		nodes, err := p.parseAtom()
+		if err != nil || len(nodes) != 1 {
+			return nodes, err
+		}
+
+		base = nodes[0]
+	}
+
+	for {
+		p.skipSpace()
+
+		if p.eof() {
+			break
+		}
+
+		c := p.src[p.pos]
+
+		if c == '\'' {
+			p.pos++
+			primes += "′"
+
+			continue
+		}
+
+		if c != '^' && c != '_' {
+			break
+		}
+
+		p.pos++
+
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case c == '^' && sup == nil:
+			sup = arg
+		case c == '_' && sub == nil:
+			sub = arg
+		default:
+			return nil, p.errorf("double %s", string(c))
+		}
+	}
+
+	if primes != "" {
+		prime := token("mo", primes)
+		if sup == nil {
+			sup = prime
+		} else {
+			sup = row(prime, sup)
+		}
+	}
+
+	if sub == nil && sup == nil {
+		return withApply(base, base), nil
+	}
+
+	if base == nil {
+		base = row()
+	}
+
+	tags := [3]string{"msub", "msup", "msubsup"}
+	if base.limits && p.display {
+		tags = [3]string{"munder", "mover", "munderover"}
+	}
+
+	var n *node
+
+	switch {
+	case sup == nil:
+		n = &node{tag: tags[0], children: []*node{base, sub}}
+	case sub == nil:
+		n = &node{tag: tags[1], children: []*node{base, sup}}
+	default:
+		n = &node{tag: tags[2], children: []*node{base, sub, sup}}
+	}
+
+	return withApply(base, n), nil
+}
+
+// withApply returns the node, followed by the function application if base is a function.
+func withApply(base, n *node) []*node {
+	if base.apply {
+		return []*node{n, token("mo", "\u2061")}
+	}
+
+	return []*node{n}
+}
+
+// parseAtom parses a single symbol, group or command.
+// Functions are followed by the invisible function application.
+func (p *parser) parseAtom() ([]*node, error) {
+	p.skipSpace()
+
+	r := p.peek()
+
+	switch {
+	case r == '{':
+		nodes, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+
+		return []*node{row(nodes...)}, nil
+	case r == '\\':
+		return p.parseCommand()
+	case r == '~':
+		p.pos++
+		return []*node{token("mtext", " ")}, nil
+	case '0' <= r && r <= '9', r == '.' && p.pos+1 < len(p.src) && '0' <= p.src[p.pos+1] && p.src[p.pos+1] <= '9':
+		start := p.pos
+		for !p.eof() {
+			c := p.src[p.pos]
+			if !('0' <= c && c <= '9') && !(c == '.' && p.pos+1 < len(p.src) && '0' <= p.src[p.pos+1] && p.src[p.pos+1] <= '9') {
+				break
+			}
+			p.pos++
+		}
+
+		return []*node{token("mn", p.src[start:p.pos])}, nil
+	case unicode.IsLetter(r):
+		p.next()
+		return []*node{token("mi", string(r))}, nil
+	}
+
+	p.next()
+
+	switch r {
+	case '-':
+		return []*node{token("mo", "−")}, nil
+	case '*':
+		return []*node{token("mo", "∗")}, nil
+	}
+
+	return []*node{token("mo", string(r))}, nil
+}
+
+// parseCommand parses a command and its arguments.
+func (p *parser) parseCommand() ([]*node, error) {
+	name := p.command()
+
+	if s, ok := identifiers[name]; ok {
+		return []*node{token("mi", s)}, nil
+	}
+
+	if s, ok := upperGreek[name]; ok {
+		return []*node{token("mi", s).attr("mathvariant", "normal")}, nil
+	}
+
+	if s, ok := operators[name]; ok {
+		return []*node{token("mo", s)}, nil
+	}
+
+	if s, ok := bigOperators[name]; ok {
+		n := token("mo", s)
+		n.limits = true
+
+		return []*node{n}, nil
+	}
+
+	if s, ok := integrals[name]; ok {
+		return []*node{token("mo", s)}, nil
+	}
+
+	if functions[name] {
+		n := token("mi", name)
+		n.apply = true
+
+		return []*node{n}, nil
+	}
+
+	if limitFunctions[name] {
+		n := token("mi", name)
+		n.limits = true
+
+		return []*node{n}, nil
+	}
+
+	if width, ok := spaces[name]; ok {
+		return []*node{(&node{tag: "mspace"}).attr("width", width)}, nil
+	}
+
+	if variant, ok := mathVariants[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		arg.setVariant(variant)
+
+		return []*node{arg}, nil
+	}
+
+	if variant, ok := textVariants[name]; ok {
+		text, err := p.parseRaw()
+		if err != nil {
+			return nil, err
+		}
+
+		n := token("mtext", strings.ReplaceAll(text, "\\", ""))
+		if variant != "" {
+			n.attr("mathvariant", variant)
+		}
+
+		return []*node{n}, nil
+	}
+
+	if a, ok := accents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		tag := "mover"
+		if a.under {
+			tag = "munder"
+		}
+
+		mark := token("mo", a.mark)
+		if a.stretchy {
+			mark.attr("stretchy", "true")
+		}
+
+		return []*node{(&node{tag: tag, children: []*node{arg, mark}}).attr("accent", "true")}, nil
+	}
+
+	if delimiterSizes[name] {
+		d, err := p.parseDelimiter()
+		if err != nil {
+			return nil, err
+		}
+
+		return []*node{token("mo", d)}, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		den, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		return []*node{{tag: "mfrac", children: []*node{num, den}}}, nil
+	case "binom":
+		top, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		bottom, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		frac := (&node{tag: "mfrac", children: []*node{top, bottom}}).attr("linethickness", "0px")
+
+		return []*node{row(token("mo", "("), frac, token("mo", ")"))}, nil
+	case "sqrt":
+		return p.parseSqrt()
+	case "operatorname":
+		name, err := p.parseRaw()
+		if err != nil {
+			return nil, err
+		}
+
+		n := token("mi", name)
+		n.apply = true
+
+		return []*node{n}, nil
+	case "left":
+		return p.parseLeftRight()
+	case "begin":
+		return p.parseEnvironment()
+	}
+
+	return nil, p.errorf("unknown command \\%s", name)
+}
+
+func (p *parser) parseSqrt() ([]*node, error) {
+	p.skipSpace()
+
+	var index []*node
+
+	if !p.eof() && p.src[p.pos] == '[' {
+		end := strings.IndexByte(p.src[p.pos:], ']')
+		if end < 0 {
+			return nil, p.errorf("expected ]")
+		}
+
+		sub := &parser{src: p.src[p.pos+1 : p.pos+end], display: p.display}
+
+		var err error
+		if index, err = sub.parseList(); err != nil {
+			return nil, err
+		}
+
+		if !sub.eof() {
+			return nil, p.errorf("unexpected %q in root index", sub.src[sub.pos:])
+		}
+
+		p.pos += end + 1
+	}
+
+	arg, err := p.parseArg()
+	if err != nil {
+		return nil, err
+	}
+
+	if index == nil {
+		return []*node{{tag: "msqrt", children: []*node{arg}}}, nil
+	}
+
+	return []*node{{tag: "mroot", children: []*node{arg, group(index)}}}, nil
+}
+
+// parseDelimiter parses the delimiter following \left, \right or \big.
+// The empty delimiter "." is returned as empty string.
+func (p *parser) parseDelimiter() (string, error) {
+	p.skipSpace()
+
+	if p.eof() {
+		return "", p.errorf("missing delimiter")
+	}
+
+	if p.src[p.pos] != '\\' {
+		r := p.next()
+		if r == '.' {
+			return "", nil
+		}
+
+		return string(r), nil
+	}
+
+	name := p.command()
+	if s, ok := operators[name]; ok {
+		return s, nil
+	}
+
+	return "", p.errorf("unknown delimiter \\%s", name)
+}
+
+func fence(d string) *node {
+	return token("mo", d).attr("fence", "true")
+}
+
+func (p *parser) parseLeftRight() ([]*node, error) {
+	open, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	inner, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekCommand() != "right" {
+		return nil, p.errorf(`expected \right`)
+	}
+
+	p.command()
+
+	closing, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	n := row()
+
+	if open != "" {
+		n.children = append(n.children, fence(open))
+	}
+
+	n.children = append(n.children, inner...)
+
+	if closing != "" {
+		n.children = append(n.children, fence(closing))
+	}
+
+	return []*node{n}, nil
+}
+
+// environments maps the supported environments to their delimiters.
+var environments = map[string][2]string{
+	"matrix":      {"", ""},
+	"smallmatrix": {"", ""},
+	"pmatrix":     {"(", ")"},
+	"bmatrix":     {"[", "]"},
+	"Bmatrix":     {"{", "}"},
+	"vmatrix":     {"|", "|"},
+	"Vmatrix":     {"‖", "‖"},
+	"cases":       {"{", ""},
+	"array":       {"", ""},
+	"aligned":     {"", ""},
+	"align":       {"", ""},
+	"align*":      {"", ""},
+	"gathered":    {"", ""},
+	"split":       {"", ""},
+}
+
+func (p *parser) parseEnvironment() ([]*node, error) {
+	name, err := p.parseRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	delims, ok := environments[name]
+	if !ok {
+		return nil, p.errorf("unknown environment %s", name)
+	}
+
+	if name == "array" {
+		// the column alignment is not supported
+		if _, err := p.parseRaw(); err != nil {
+			return nil, err
+		}
+	}
+
+	table := &node{tag: "mtable"}
+	tr := &node{tag: "mtr"}
+
+	for {
+		cell, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		tr.children = append(tr.children, &node{tag: "mtd", children: cell})
+
+		switch {
+		case p.eof():
+			return nil, p.errorf(`expected \end{%s}`, name)
+		case p.src[p.pos] == '&':
+			p.pos++
+			continue
+		case p.src[p.pos] == '}':
+			return nil, p.errorf("unexpected }")
+		}
+
+		cmd := p.command()
+
+		// a trailing \\ does not start a new row
+		if cmd == "\\" && !p.atEndOf(name) {
+			table.children = append(table.children, tr)
+			tr = &node{tag: "mtr"}
+
+			continue
+		}
+
+		if cmd == "\\" {
+			p.command()
+		} else if cmd != "end" {
+			return nil, p.errorf(`unexpected \%s`, cmd)
+		}
+
+		end, err := p.parseRaw()
+		if err != nil {
+			return nil, err
+		}
+
+		if end != name {
+			return nil, p.errorf(`expected \end{%s}`, name)
+		}
+
+		table.children = append(table.children, tr)
+
+		break
+	}
+
+	if delims == [2]string{} {
+		return []*node{table}, nil
+	}
+
+	n := row()
+
+	if delims[0] != "" {
+		n.children = append(n.children, fence(delims[0]))
+	}
+
+	n.children = append(n.children, table)
+
+	if delims[1] != "" {
+		n.children = append(n.children, fence(delims[1]))
+	}
+
+	return []*node{n}, nil
+}
+
+// atEndOf reports whether the environment ends at the current position.
+func (p *parser) atEndOf(name string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.src[p.pos:], `\end{`+name+`}`)
+}
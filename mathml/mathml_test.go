@@ -0,0 +1,111 @@
+package mathml_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/notion-to-goldmark/mathml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert_Export(t *testing.T) {
+	t.Parallel()
+
+	html, err := fake.HTMLExport.ReadFile("html/Example Page 96245c8f178444a482ad1941127c3ec3.html")
+	assert.NoError(t, err)
+
+	// the inline equation and the equation block
+	want := regexp.MustCompile(`<math.*?</math>`).FindAllString(string(html), -1)
+	if !assert.Len(t, want, 2) {
+		return
+	}
+
+	got, err := mathml.Convert(`e^{\pi i}+1=0`, false)
+	assert.NoError(t, err)
+	assert.Equal(t, want[0], got)
+
+	got, err = mathml.Convert(`e^{\pi i}+1 = 0`, true)
+	assert.NoError(t, err)
+	assert.Equal(t, want[1], got)
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		tex     string
+		display bool
+		want    string
+	}{
+		{tex: `x`, want: `<mrow><mi>x</mi></mrow>`},
+		{tex: `x_1^2`, want: `<mrow><msubsup><mi>x</mi><mn>1</mn><mn>2</mn></msubsup></mrow>`},
+		{tex: `x^23`, want: `<mrow><msup><mi>x</mi><mn>2</mn></msup><mn>3</mn></mrow>`},
+		{tex: `3.14 - a*b`, want: `<mrow><mn>3.14</mn><mo>−</mo><mi>a</mi><mo>∗</mo><mi>b</mi></mrow>`},
+		{tex: `f'(x) < 1`, want: `<mrow><msup><mi>f</mi><mo>′</mo></msup><mo>(</mo><mi>x</mi><mo>)</mo><mo>&lt;</mo><mn>1</mn></mrow>`},
+		{tex: `\frac{1}{2}`, want: `<mrow><mfrac><mn>1</mn><mn>2</mn></mfrac></mrow>`},
+		{tex: `\sqrt{x}\sqrt[3]{y}`, want: `<mrow><msqrt><mi>x</mi></msqrt><mroot><mi>y</mi><mn>3</mn></mroot></mrow>`},
+		{tex: `\Gamma(\alpha)`, want: `<mrow><mi mathvariant="normal">Γ</mi><mo>(</mo><mi>α</mi><mo>)</mo></mrow>`},
+		{tex: `\sin x`, want: `<mrow><mi>sin</mi><mo>` + "⁡" + `</mo><mi>x</mi></mrow>`},
+		{tex: `\operatorname{sgn}_2`, want: `<mrow><msub><mi>sgn</mi><mn>2</mn></msub><mo>` + "⁡" + `</mo></mrow>`},
+		{tex: `\sum_{i=1}^n i`, want: `<mrow><msubsup><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup><mi>i</mi></mrow>`},
+		{
+			tex: `\sum_{i=1}^n i`, display: true,
+			want: `<mrow><munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`,
+		},
+		{tex: `\lim_{x \to 0}`, display: true, want: `<mrow><munder><mi>lim</mi><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder></mrow>`},
+		{tex: `\int_0^1`, display: true, want: `<mrow><msubsup><mo>∫</mo><mn>0</mn><mn>1</mn></msubsup></mrow>`},
+		{tex: `\mathbb{R}\mathbf{v1}`, want: `<mrow><mi mathvariant="double-struck">R</mi><mrow><mi mathvariant="bold">v</mi><mn mathvariant="bold">1</mn></mrow></mrow>`},
+		{tex: `\text{if } x`, want: `<mrow><mtext>if </mtext><mi>x</mi></mrow>`},
+		{tex: `a\,b\quad c`, want: `<mrow><mi>a</mi><mspace width="0.1667em"/><mi>b</mi><mspace width="1em"/><mi>c</mi></mrow>`},
+		{tex: `\hat{x}`, want: `<mrow><mover accent="true"><mi>x</mi><mo>^</mo></mover></mrow>`},
+		{tex: `\left( x \right.`, want: `<mrow><mo fence="true">(</mo><mi>x</mi></mrow>`},
+		{tex: `\left\{ x \right\}`, want: `<mrow><mo fence="true">{</mo><mi>x</mi><mo fence="true">}</mo></mrow>`},
+		{tex: `\bigl( x \bigr)`, want: `<mrow><mo>(</mo><mi>x</mi><mo>)</mo></mrow>`},
+		{tex: `{a}`, want: `<mrow><mi>a</mi></mrow>`},
+		{tex: `a \\ b`, want: `<mrow><mi>a</mi><mspace linebreak="newline"/><mi>b</mi></mrow>`},
+		{
+			tex:  `\begin{pmatrix} a & b \\ c & d \end{pmatrix}`,
+			want: `<mrow><mo fence="true">(</mo><mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable><mo fence="true">)</mo></mrow>`,
+		},
+		{
+			tex:  `\begin{cases} 1 & x > 0 \\ 0 & \text{else} \\ \end{cases}`,
+			want: `<mrow><mo fence="true">{</mo><mtable><mtr><mtd><mn>1</mn></mtd><mtd><mi>x</mi><mo>&gt;</mo><mn>0</mn></mtd></mtr><mtr><mtd><mn>0</mn></mtd><mtd><mtext>else</mtext></mtd></mtr></mtable></mrow>`,
+		},
+		{
+			tex:  `\begin{matrix} a \end{matrix}`,
+			want: `<mtable><mtr><mtd><mi>a</mi></mtd></mtr></mtable>`,
+		},
+	} {
+		got, err := mathml.Convert(tt.tex, tt.display)
+		if !assert.NoError(t, err, tt.tex) {
+			continue
+		}
+
+		start := strings.Index(got, "<semantics>") + len("<semantics>")
+		end := strings.Index(got, "<annotation")
+		assert.Equal(t, tt.want, got[start:end], tt.tex)
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	t.Parallel()
+
+	for tex, want := range map[string]string{
+		`\foo`:                       `position 4: unknown command \foo`,
+		`{x`:                         `position 2: expected }`,
+		`x}`:                         `position 1: unexpected "}"`,
+		`a & b`:                      `position 2: unexpected "&"`,
+		`x^`:                         `position 2: missing argument`,
+		`x^1^2`:                      `position 5: double ^`,
+		`\frac{1}`:                   `position 8: missing argument`,
+		`\left( x`:                   `position 8: expected \right`,
+		`\begin{foo}`:                `position 11: unknown environment foo`,
+		`\begin{matrix} a`:           `position 16: expected \end{matrix}`,
+		`\begin{matrix} a \end{bar}`: `position 26: expected \end{matrix}`,
+	} {
+		_, err := mathml.Convert(tex, false)
+		assert.EqualError(t, err, want, tex)
+	}
+}
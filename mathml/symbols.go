@@ -0,0 +1,118 @@
+package mathml
+
+// identifiers are the commands of letters and symbols that are rendered as identifiers.
+var identifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ",
+	"rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ",
+	"phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+
+	"infty": "∞", "partial": "∂", "nabla": "∇", "hbar": "ℏ", "ell": "ℓ", "emptyset": "∅",
+	"varnothing": "∅", "aleph": "ℵ", "Re": "ℜ", "Im": "ℑ", "wp": "℘", "imath": "ı", "jmath": "ȷ",
+	"%": "%", "$": "$", "#": "#", "_": "_",
+}
+
+// upperGreek are the upright uppercase Greek letters.
+var upperGreek = map[string]string{
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+// operators are the commands of symbols that are rendered as operators,
+// including relations, arrows and delimiters.
+var operators = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "∙", "cap": "∩", "cup": "∪", "setminus": "∖", "wedge": "∧",
+	"land": "∧", "vee": "∨", "lor": "∨", "neg": "¬", "lnot": "¬", "oplus": "⊕",
+	"ominus": "⊖", "otimes": "⊗", "odot": "⊙", "dagger": "†", "ddagger": "‡",
+
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪",
+	"gg": "≫", "in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "supset": "⊃",
+	"subseteq": "⊆", "supseteq": "⊇", "mid": "∣", "parallel": "∥", "perp": "⊥",
+	"models": "⊨", "vdash": "⊢", "prec": "≺", "succ": "≻", "doteq": "≐",
+
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "Rightarrow": "⇒",
+	"Leftarrow": "⇐", "leftrightarrow": "↔", "Leftrightarrow": "⇔", "iff": "⟺",
+	"implies": "⟹", "impliedby": "⟸", "mapsto": "↦", "uparrow": "↑", "downarrow": "↓",
+	"longrightarrow": "⟶", "longleftarrow": "⟵", "hookrightarrow": "↪",
+
+	"forall": "∀", "exists": "∃", "nexists": "∄", "angle": "∠", "triangle": "△",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱", "colon": ":",
+	"prime": "′", "therefore": "∴", "because": "∵",
+
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"vert": "|", "Vert": "‖", "|": "‖", "{": "{", "}": "}", "lbrace": "{", "rbrace": "}",
+	"backslash": "\\", "&": "&",
+}
+
+// bigOperators are the operators whose scripts are placed below and above them in display mode.
+var bigOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂",
+	"bigvee": "⋁", "bigwedge": "⋀", "bigoplus": "⨁", "bigotimes": "⨂", "bigodot": "⨀",
+}
+
+// integrals keep their scripts at the side, even in display mode.
+var integrals = map[string]string{
+	"int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+}
+
+// functions are the names of functions that are written upright.
+var functions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true, "tanh": true,
+	"coth": true, "log": true, "ln": true, "lg": true, "exp": true, "det": true, "dim": true,
+	"ker": true, "deg": true, "gcd": true, "hom": true, "arg": true,
+}
+
+// limitFunctions are functions whose scripts are placed below and above them in display mode.
+var limitFunctions = map[string]bool{
+	"lim": true, "liminf": true, "limsup": true, "max": true, "min": true,
+	"sup": true, "inf": true, "Pr": true,
+}
+
+// spaces maps the spacing commands to their widths.
+var spaces = map[string]string{
+	",": "0.1667em", "thinspace": "0.1667em", ":": "0.2222em", ">": "0.2222em",
+	"medspace": "0.2222em", ";": "0.2778em", "thickspace": "0.2778em", "!": "-0.1667em",
+	" ": "0.3333em", "quad": "1em", "qquad": "2em",
+}
+
+// mathVariants maps the font commands to their math variants.
+var mathVariants = map[string]string{
+	"mathrm": "normal", "mathbf": "bold", "mathit": "italic", "mathbb": "double-struck",
+	"mathcal": "script", "mathscr": "script", "mathfrak": "fraktur", "mathsf": "sans-serif",
+	"mathtt": "monospace", "boldsymbol": "bold-italic", "bm": "bold-italic",
+}
+
+// textVariants maps the text commands to their math variants.
+var textVariants = map[string]string{
+	"text": "", "textrm": "", "mbox": "", "textbf": "bold", "textit": "italic",
+	"textsf": "sans-serif", "texttt": "monospace",
+}
+
+type accent struct {
+	mark     string
+	stretchy bool
+	under    bool
+}
+
+// accents are the commands that place a mark above or below their argument.
+var accents = map[string]accent{
+	"hat": {mark: "^"}, "widehat": {mark: "^", stretchy: true}, "check": {mark: "ˇ"},
+	"tilde": {mark: "~"}, "widetilde": {mark: "~", stretchy: true}, "bar": {mark: "ˉ"},
+	"vec": {mark: "⃗"}, "dot": {mark: "˙"}, "ddot": {mark: "¨"}, "acute": {mark: "ˊ"},
+	"grave": {mark: "ˋ"}, "breve": {mark: "˘"},
+	"overline": {mark: "‾", stretchy: true}, "underline": {mark: "‾", stretchy: true, under: true},
+	"overrightarrow": {mark: "→", stretchy: true}, "overleftarrow": {mark: "←", stretchy: true},
+}
+
+// delimiterSizes are the commands that size the following delimiter,
+// which is displayed at its normal size.
+var delimiterSizes = map[string]bool{
+	"big": true, "Big": true, "bigg": true, "Bigg": true,
+	"bigl": true, "Bigl": true, "biggl": true, "Biggl": true,
+	"bigr": true, "Bigr": true, "biggr": true, "Biggr": true,
+	"bigm": true, "Bigm": true, "biggm": true, "Biggm": true,
+}
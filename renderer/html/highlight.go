@@ -0,0 +1,50 @@
+package html
+
+import (
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/yuin/goldmark/util"
+)
+
+// A Highlighter highlights code on the server, e.g. with Chroma.
+type Highlighter interface {
+	// Highlight writes the highlighted code, which ends up within <pre><code>.
+	// The language is the info string of the code block,
+	// which for converted Notion pages is the name of a Chroma lexer.
+	// It is empty if the language is unknown.
+	Highlight(w util.BufWriter, code []byte, language string) error
+}
+
+// HighlighterFunc is an adapter to use a function as a Highlighter.
+type HighlighterFunc func(w util.BufWriter, code []byte, language string) error
+
+// Highlight implements Highlighter.
+func (f HighlighterFunc) Highlight(w util.BufWriter, code []byte, language string) error {
+	return f(w, code, language)
+}
+
+// ChromaHighlighter returns a Highlighter that highlights code with Chroma,
+// using inline styles of the given Chroma style, e.g. "github".
+// Unknown styles fall back to Chroma's default style
+// and code in unknown languages is not highlighted.
+func ChromaHighlighter(style string) Highlighter {
+	s := styles.Get(style)
+	f := chromahtml.New(chromahtml.PreventSurroundingPre(true))
+
+	return HighlighterFunc(func(w util.BufWriter, code []byte, language string) error {
+		lexer := lexers.Get(language)
+		if language == "" || lexer == nil {
+			_, _ = w.Write(util.EscapeHTML(code))
+			return nil
+		}
+
+		it, err := chroma.Coalesce(lexer).Tokenise(nil, string(code))
+		if err != nil {
+			return err
+		}
+
+		return f.Format(w, s, it)
+	})
+}
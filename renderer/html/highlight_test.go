@@ -0,0 +1,45 @@
+package html_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/faetools/notion-to-goldmark/renderer/html"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+)
+
+func TestWithChromaHighlighting(t *testing.T) {
+	t.Parallel()
+
+	md := goldmark.New(goldmark.WithExtensions(
+		html.NewExtender(html.WithChromaHighlighting("github"))))
+
+	for name, tt := range map[string]struct {
+		src, want string
+	}{
+		"go": {
+			src:  "```go\nreturn \"<b>\"\n```\n",
+			want: `<pre><code><span style="display:flex;"><span><span style="color:#000;font-weight:bold">return</span> <span style="color:#d14">&#34;&lt;b&gt;&#34;</span>` + "\n</span></span></code></pre>",
+		},
+		"unknown language": {
+			src:  "```not-a-language\na < b\n```\n",
+			want: `<pre><code>a &lt; b` + "\n</code></pre>",
+		},
+		"no language": {
+			src:  "```\na < b\n```\n",
+			want: "<pre><code>a &lt; b\n</code></pre>",
+		},
+	} {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			if assert.NoError(t, md.Convert([]byte(tt.src), buf)) {
+				assert.Equal(t, tt.want, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,988 @@
+// Package html renders the nodes of a converted Notion page as HTML,
+// reproducing the markup of Notion's own HTML export.
+package html
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Notion is an extension that renders all Notion nodes the way Notion exports them.
+//
+//	md := goldmark.New(goldmark.WithExtensions(html.Notion))
+var Notion = NewExtender()
+
+// NewExtender returns an extension like Notion whose Renderer is configured with opts.
+//
+//	md := goldmark.New(goldmark.WithExtensions(html.NewExtender(html.WithMathRenderer(html.MathML))))
+func NewExtender(opts ...Option) goldmark.Extender {
+	return &extender{opts: opts}
+}
+
+type extender struct {
+	opts []Option
+}
+
+// Extend implements goldmark.Extender.
+func (e *extender) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(extension.NewStrikethroughHTMLRenderer(), 100),
+		util.Prioritized(NewRenderer(e.opts...), 100),
+	))
+}
+
+// Option configures the Renderer.
+type Option func(*Renderer)
+
+// WithMathRenderer sets how equations are rendered. The default is KaTeX.
+func WithMathRenderer(m MathRenderer) Option {
+	return func(r *Renderer) { r.math = m }
+}
+
+// WithHighlighter highlights the code of code blocks.
+// By default, the code is not highlighted, like in Notion's export.
+func WithHighlighter(h Highlighter) Option {
+	return func(r *Renderer) { r.highlighter = h }
+}
+
+// WithChromaHighlighting highlights the code of code blocks with Chroma,
+// using the given Chroma style, e.g. "github".
+func WithChromaHighlighting(style string) Option {
+	return WithHighlighter(ChromaHighlighter(style))
+}
+
+// Renderer is a renderer.NodeRenderer that renders Notion nodes
+// the way Notion's HTML export does.
+type Renderer struct {
+	math        MathRenderer
+	highlighter Highlighter
+}
+
+// NewRenderer returns a new Renderer.
+func NewRenderer(opts ...Option) renderer.NodeRenderer {
+	r := &Renderer{math: KaTeX}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *Renderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	renderDiv := renderTag("div", html.GlobalAttributeFilter)
+	renderFigure := renderTag("figure", html.GlobalAttributeFilter)
+
+	// goldmark nodes
+	reg.Register(ast.KindDocument, r.renderDocument)
+	reg.Register(ast.KindParagraph, r.renderParagraph)
+	reg.Register(ast.KindHeading, r.renderHeading)
+	reg.Register(ast.KindBlockquote, renderTag("blockquote", html.BlockquoteAttributeFilter))
+	reg.Register(ast.KindCodeSpan, renderTag("code", html.CodeAttributeFilter))
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+	reg.Register(ast.KindThematicBreak, renderThematicBreak)
+	reg.Register(ast.KindImage, r.renderImage)
+	reg.Register(ast.KindLink, r.renderLink)
+	reg.Register(ast.KindTextBlock, r.renderTextBlock)
+
+	// notion prints all list items in their own list
+	reg.Register(ast.KindList, noop)
+	reg.Register(ast.KindListItem, r.renderListItem)
+
+	reg.Register(extast.KindTaskCheckBox, renderDiv)
+	reg.Register(extast.KindTable, renderTag("table", extension.TableAttributeFilter))
+	reg.Register(extast.KindTableHeader, r.renderTableHeader)
+	reg.Register(extast.KindTableRow, r.renderTableRow)
+	reg.Register(extast.KindTableCell, r.renderTableCell)
+
+	// notion nodes
+	reg.Register(n_ast.KindBlockChildren, r.renderBlockChildren)
+	reg.Register(n_ast.KindBookmark, r.renderBookmark)
+	reg.Register(n_ast.KindCallout, renderFigure)
+	reg.Register(n_ast.KindCalloutText, r.renderCalloutText)
+	reg.Register(n_ast.KindCaption, renderTag("figcaption", html.GlobalAttributeFilter))
+	reg.Register(n_ast.KindCheckboxText, renderTag("span", html.ListItemAttributeFilter))
+	reg.Register(n_ast.KindChildDatabase, renderDiv)
+	reg.Register(n_ast.KindChildPage, renderFigure)
+	reg.Register(n_ast.KindChildren, noop)
+	reg.Register(n_ast.KindColor, r.renderColor)
+	reg.Register(n_ast.KindColumn, r.renderColumn)
+	reg.Register(n_ast.KindColumnList, renderDiv)
+	reg.Register(n_ast.KindDatabaseCard, r.renderDatabaseCard)
+	reg.Register(n_ast.KindDatabaseCardProperty, renderTag("span", html.GlobalAttributeFilter))
+	reg.Register(n_ast.KindDatabaseGroup, r.renderDatabaseGroup)
+	reg.Register(n_ast.KindDatabaseView, r.renderDatabaseView)
+	reg.Register(n_ast.KindDate, r.renderDate)
+	reg.Register(n_ast.KindEmbed, renderFigure)
+	reg.Register(n_ast.KindEmbedSource, r.renderEmbedSource)
+	reg.Register(n_ast.KindEquation, r.renderEquation)
+	reg.Register(n_ast.KindEquationBlock, r.renderEquationBlock)
+	reg.Register(n_ast.KindFile, renderFigure)
+	reg.Register(n_ast.KindFileInCell, r.renderFileInCell)
+	reg.Register(n_ast.KindIcon, r.renderIcon)
+	reg.Register(n_ast.KindLinkPreview, r.renderLinkPreview)
+	reg.Register(n_ast.KindLinkToPage, renderFigure)
+	reg.Register(n_ast.KindMention, r.renderMention)
+	reg.Register(n_ast.KindPageHeader, r.renderPageHeader)
+	reg.Register(n_ast.KindPolygon, renderTag("polygon", polygonFilter))
+	reg.Register(n_ast.KindPropertyIcon, renderTag("span", html.GlobalAttributeFilter))
+	reg.Register(n_ast.KindSelect, r.renderSelect)
+	reg.Register(n_ast.KindStatus, r.renderStatus)
+	reg.Register(n_ast.KindSVG, renderTag("svg", svgFilter))
+	reg.Register(n_ast.KindSVGPath, renderTag("path", pathFilter))
+	reg.Register(n_ast.KindSyncedBlock, renderDiv)
+	reg.Register(n_ast.KindTableOfContents, renderTag("nav", html.GlobalAttributeFilter))
+	reg.Register(n_ast.KindToggle, r.renderToggle)
+	reg.Register(n_ast.KindToggleText, renderTag("summary", html.GlobalAttributeFilter))
+	reg.Register(n_ast.KindUnderline, r.renderUnderline)
+	reg.Register(n_ast.KindUnsupported, r.renderUnsupported)
+	reg.Register(n_ast.KindUser, r.renderUser)
+	reg.Register(n_ast.KindVideo, renderFigure)
+}
+
+func (r *Renderer) renderParagraph(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</p>")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<p")
+	html.RenderAttributes(w, n, html.ParagraphAttributeFilter)
+	_ = w.WriteByte('>')
+
+	if !n.HasChildren() {
+		// for some reason, notion adds a new line here
+		_ = w.WriteByte('\n')
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	if entering {
+		_, _ = w.WriteString("<h")
+		_ = w.WriteByte("0123456"[n.Level])
+		html.RenderAttributes(w, node, html.HeadingAttributeFilter)
+	} else {
+		_, _ = w.WriteString("</h")
+		_ = w.WriteByte("0123456"[n.Level])
+	}
+
+	_ = w.WriteByte('>')
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.FencedCodeBlock)
+
+	_, _ = w.WriteString("<pre")
+	html.RenderAttributes(w, n, html.CodeAttributeFilter)
+	_, _ = w.WriteString("><code>")
+
+	var code []byte
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		code = append(code, line.Value(source)...)
+	}
+
+	if r.highlighter != nil {
+		if err := r.highlighter.Highlight(w, code, string(n.Language(source))); err != nil {
+			return ast.WalkStop, err
+		}
+	} else {
+		_, _ = w.Write(util.EscapeHTML(code))
+	}
+
+	_, _ = w.WriteString("</code></pre>")
+
+	// the caption is ignored, like in Notion's export
+	return ast.WalkSkipChildren, nil
+}
+
+func renderThematicBreak(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<hr")
+		html.RenderAttributes(w, n, html.ThematicAttributeFilter)
+		_, _ = w.WriteString("/>")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Image)
+
+	_, _ = w.WriteString("<img")
+	html.RenderAttributes(w, n, html.ImageAttributeFilter)
+
+	_, _ = w.WriteString(` src="`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape(safeURL(n.Destination), true)))
+	_ = w.WriteByte('"')
+
+	if alt := n.Text(source); len(alt) > 0 {
+		_, _ = w.WriteString(` alt="`)
+		_, _ = w.Write(util.EscapeHTML(alt))
+		_ = w.WriteByte('"')
+	}
+
+	if n.Title != nil {
+		_, _ = w.WriteString(` title="`)
+		_, _ = w.Write(escapeHTML(n.Title))
+		_ = w.WriteByte('"')
+	}
+
+	_, _ = w.WriteString("/>")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</a>")
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Link)
+
+	if hasAncestor(node, n_ast.KindTableOfContents) {
+		_, _ = w.WriteString(`<a class="table_of_contents-link" href="`)
+	} else {
+		_, _ = w.WriteString(`<a href="`)
+	}
+
+	_, _ = w.Write(util.EscapeHTML(safeURL(n.Destination)))
+	_ = w.WriteByte('"')
+
+	if n.Title != nil {
+		_, _ = w.WriteString(` title="`)
+		_, _ = w.Write(escapeHTML(n.Title))
+		_ = w.WriteByte('"')
+	}
+
+	html.RenderAttributes(w, n, html.LinkAttributeFilter)
+
+	_ = w.WriteByte('>')
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTextBlock(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	// each heading in the table of contents is in its own div
+	if hasAncestor(n, n_ast.KindTableOfContents) {
+		return renderTag("div", html.GlobalAttributeFilter)(w, source, n, entering)
+	}
+
+	if !entering && n.NextSibling() != nil && n.FirstChild() != nil {
+		_ = w.WriteByte('\n')
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderListItem(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	// notion lists all headings of the table of contents one after another
+	if hasAncestor(n, n_ast.KindTableOfContents) {
+		return ast.WalkContinue, nil
+	}
+
+	p := n.Parent().(*ast.List)
+
+	tag := "ul"
+	if p.IsOrdered() {
+		tag = "ol"
+	}
+
+	if !entering {
+		_, _ = w.WriteString("</li></")
+		_, _ = w.WriteString(tag)
+		_ = w.WriteByte('>')
+		return ast.WalkContinue, nil
+	}
+
+	_ = w.WriteByte('<')
+	_, _ = w.WriteString(tag)
+
+	depth := listDepth(p, p.IsOrdered())
+
+	if p.IsOrdered() {
+		// different types depending on depth
+		_, _ = w.WriteString(` type="`)
+		_, _ = w.WriteString([]string{"1", "a", "i"}[depth%3])
+		_ = w.WriteByte('"')
+	}
+
+	html.RenderAttributes(w, n, idFilter)
+
+	// e.g. class="block-color-red_background numbered-list" or class="red to-do-list"
+	renderClass(w, n, p)
+
+	if p.IsOrdered() {
+		_, _ = w.WriteString(` start="`)
+		_, _ = w.WriteString(strconv.Itoa(numPrevious(n) + 1))
+		_ = w.WriteByte('"')
+	}
+
+	isToDo := false
+	if c := n.FirstChild(); c != nil && c.Kind() == extast.KindTaskCheckBox {
+		isToDo = true
+	}
+
+	_, _ = w.WriteString("><li")
+	if !p.IsOrdered() && !isToDo {
+		// different styles depending on depth
+		_, _ = w.WriteString(` style="list-style-type:`)
+		_, _ = w.WriteString([]string{"disc", "circle", "square"}[depth%3])
+		_ = w.WriteByte('"')
+	}
+
+	_ = w.WriteByte('>')
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTableHeader(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		// the header row has no separate node, so it takes the ID of the header
+		_, _ = w.WriteString("<thead")
+		html.RenderAttributes(w, n, classFilter)
+		_, _ = w.WriteString("><tr")
+		html.RenderAttributes(w, n, idFilter)
+		_ = w.WriteByte('>')
+	} else {
+		_, _ = w.WriteString("</tr>")
+		_, _ = w.WriteString("</thead>")
+		if n.NextSibling() != nil {
+			_, _ = w.WriteString("<tbody>")
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTableRow(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if n.PreviousSibling() == nil {
+			// there is no header
+			_, _ = w.WriteString("<tbody>")
+		}
+
+		_, _ = w.WriteString("<tr")
+		html.RenderAttributes(w, n, extension.TableRowAttributeFilter)
+		_, _ = w.WriteString(">")
+	} else {
+		_, _ = w.WriteString("</tr>")
+
+		if n.Parent().LastChild() == n {
+			_, _ = w.WriteString("</tbody>")
+		}
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTableCell(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*extast.TableCell)
+
+	_, isRowHeader := n.AttributeString("scope")
+
+	tag := "td"
+	if isRowHeader || n.Parent().Kind() == extast.KindTableHeader {
+		tag = "th"
+	}
+
+	if !entering {
+		fmt.Fprintf(w, "</%s>", tag)
+		return ast.WalkContinue, nil
+	}
+
+	fmt.Fprintf(w, "<%s", tag)
+
+	if n.Alignment != extast.AlignNone {
+		if _, ok := n.AttributeString("align"); !ok { // Skip align render if overridden
+			fmt.Fprintf(w, ` align="%s"`, n.Alignment.String())
+		}
+	}
+
+	switch {
+	case isRowHeader:
+		// notion does not mark row headers with a scope
+		html.RenderAttributes(w, n, html.GlobalAttributeFilter)
+	case tag == "td":
+		html.RenderAttributes(w, n, extension.TableTdCellAttributeFilter) // <td>
+	default:
+		html.RenderAttributes(w, n, extension.TableThCellAttributeFilter) // <th>
+	}
+
+	_ = w.WriteByte('>')
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderBlockChildren(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	switch n.Parent().Kind() {
+	case n_ast.KindCalloutText, n_ast.KindSyncedBlock,
+		ast.KindListItem, n_ast.KindToggle, n_ast.KindChildPage:
+		// NOTE: maybe we change the implementation so as to not use KindBlockChildren
+		// and instead call it "indented" or something
+		return ast.WalkContinue, nil
+	}
+
+	if entering {
+		_, _ = w.WriteString(`<div class="indented">`)
+	} else {
+		_, _ = w.WriteString(`</div>`)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderBookmark(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</figure>")
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*n_ast.Bookmark)
+	url := util.EscapeHTML([]byte(n.URL))
+
+	// without metadata, notion uses the URL as title
+	title := url
+	if n.Title != "" {
+		title = util.EscapeHTML([]byte(n.Title))
+	}
+
+	_, _ = w.WriteString("<figure")
+	html.RenderAttributes(w, n, html.GlobalAttributeFilter)
+	_, _ = w.WriteString(`><a href="`)
+	_, _ = w.Write(util.EscapeHTML(safeURL([]byte(n.URL))))
+	_, _ = w.WriteString(`" class="bookmark source"><div class="bookmark-info"><div class="bookmark-text"><div class="bookmark-title">`)
+	_, _ = w.Write(title)
+	_, _ = w.WriteString(`</div>`)
+
+	if n.Description != "" {
+		_, _ = w.WriteString(`<div class="bookmark-description">`)
+		_, _ = w.Write(util.EscapeHTML([]byte(n.Description)))
+		_, _ = w.WriteString(`</div>`)
+	}
+
+	_, _ = w.WriteString(`</div><div class="bookmark-href">`)
+
+	if n.Favicon != "" {
+		_, _ = w.WriteString(`<img src="`)
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape(safeURL([]byte(n.Favicon)), true)))
+		_, _ = w.WriteString(`" class="icon bookmark-icon"/>`)
+	}
+
+	_, _ = w.Write(url)
+	_, _ = w.WriteString(`</div></div>`)
+
+	if n.Image != "" {
+		_, _ = w.WriteString(`<img src="`)
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape(safeURL([]byte(n.Image)), true)))
+		_, _ = w.WriteString(`" class="bookmark-image"/>`)
+	}
+
+	_, _ = w.WriteString(`</a>`)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderCalloutText(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<div style="width:100%">`)
+	} else {
+		_, _ = w.WriteString(`</div>`)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderColor(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</mark>")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<mark class="highlight-`)
+	_, _ = w.WriteString(string(n.(*n_ast.Color).Color))
+	_, _ = w.WriteString(`">`)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderColumn(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</div>")
+		return ast.WalkContinue, nil
+	}
+
+	// notion divides the width evenly between the columns
+	width := 100.0
+	if p := n.Parent(); p != nil {
+		width /= float64(p.ChildCount())
+	}
+
+	_, _ = w.WriteString("<div")
+	html.RenderAttributes(w, n, idFilter)
+	_, _ = w.WriteString(` style="width:`)
+	_, _ = w.WriteString(strconv.FormatFloat(width, 'f', -1, 64))
+	_, _ = w.WriteString(`%"`)
+	renderClass(w, n)
+	_ = w.WriteByte('>')
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderDatabaseView(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*n_ast.DatabaseView)
+
+	tag := "div"
+	if n.Layout == n_ast.DatabaseLayoutList {
+		tag = "ul"
+	}
+
+	if !entering {
+		_, _ = w.WriteString("</")
+		_, _ = w.WriteString(tag)
+		_ = w.WriteByte('>')
+		return ast.WalkContinue, nil
+	}
+
+	_ = w.WriteByte('<')
+	_, _ = w.WriteString(tag)
+	html.RenderAttributes(w, n, idFilter)
+	_, _ = w.WriteString(` class="collection-view collection-`)
+	_, _ = w.WriteString(string(n.Layout))
+	_, _ = w.WriteString(`">`)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderDatabaseGroup(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</div>")
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*n_ast.DatabaseGroup)
+
+	if n.Value == nil {
+		_, _ = w.WriteString(`<div class="collection-group calendar-day"><h4><time datetime="`)
+		_, _ = w.WriteString(n.Day.Format("2006-01-02"))
+		_, _ = w.WriteString(`">`)
+		_, _ = w.WriteString(formatTime(n.Day, false))
+		_, _ = w.WriteString(`</time></h4>`)
+
+		return ast.WalkContinue, nil
+	}
+
+	// the header of a column looks like the value of a select property
+	_, _ = w.WriteString(`<div class="collection-group board-column"><h4><span class="selected-value`)
+
+	switch n.Value.Color {
+	case notion.ColorDefault, "": // no color
+	default:
+		_, _ = w.WriteString(` select-value-color-`)
+		_, _ = w.WriteString(string(n.Value.Color))
+	}
+
+	_, _ = w.WriteString(`">`)
+	_, _ = w.Write(escapeHTML([]byte(n.Value.Name)))
+	_, _ = w.WriteString(`</span></h4>`)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderDatabaseCard(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*n_ast.DatabaseCard)
+
+	isListItem := false
+	if v, ok := n.Parent().(*n_ast.DatabaseView); ok && v.Layout == n_ast.DatabaseLayoutList {
+		isListItem = true
+	}
+
+	if !entering {
+		if isListItem {
+			_, _ = w.WriteString("</li>")
+		} else {
+			_, _ = w.WriteString("</div>")
+		}
+
+		return ast.WalkContinue, nil
+	}
+
+	if isListItem {
+		_, _ = w.WriteString("<li")
+		html.RenderAttributes(w, n, idFilter)
+		_, _ = w.WriteString(` class="collection-list-item">`)
+
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<div")
+	html.RenderAttributes(w, n, idFilter)
+	_, _ = w.WriteString(` class="collection-card">`)
+
+	if n.Cover != "" {
+		_, _ = w.WriteString(`<img class="collection-card-cover" src="`)
+		_, _ = w.Write(util.EscapeHTML(safeURL([]byte(n.Cover))))
+		_, _ = w.WriteString(`"/>`)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderDate(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString(`</time>`)
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<time>`)
+
+	n := node.(*n_ast.Date)
+
+	if n.Date != nil {
+		writeDate(w, n.Date, n.TwelveHourClock)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func writeDate(w util.BufWriter, d *notion.Date, twelveHourClock bool) {
+	_ = w.WriteByte('@')
+
+	_, _ = w.WriteString(formatTime(d.Start, twelveHourClock))
+
+	if d.End == nil {
+		return
+	}
+
+	_, _ = w.WriteString(" → ")
+	_, _ = w.WriteString(formatTime(*d.End, twelveHourClock))
+}
+
+func (r *Renderer) renderEmbedSource(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	tag := "div"
+
+	if f, ok := n.Parent().(*n_ast.File); ok {
+		switch f.FileType() {
+		case n_ast.FileTypeImage:
+			// the link with the image is rendered directly
+			return ast.WalkContinue, nil
+		case n_ast.FileTypeVideo, n_ast.FileTypeAudio:
+			// the link is rendered as a fallback for old browsers
+			tag = string(f.FileType())
+		}
+	}
+
+	if !entering {
+		_, _ = w.WriteString("</" + tag + ">")
+		return ast.WalkContinue, nil
+	}
+
+	if tag == "div" {
+		_, _ = w.WriteString(`<div class="source">`)
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<" + tag + ` controls="" src="`)
+	_, _ = w.Write(util.EscapeHTML(safeURL(n.Parent().(*n_ast.File).Destination())))
+	_, _ = w.WriteString(`">`)
+
+	return ast.WalkContinue, nil
+}
+
+// renderDocument writes the stylesheet of the math renderer once if the document has equations.
+func (r *Renderer) renderDocument(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	s, ok := r.math.(MathStyler)
+	if !entering || !ok || !hasEquations(node) {
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(s.MathStyle())
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderEquation(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	return ast.WalkSkipChildren, r.math.RenderMath(w, node.(*n_ast.Equation).Expression, false)
+}
+
+func (r *Renderer) renderEquationBlock(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</figure>")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<figure")
+	html.RenderAttributes(w, node, html.GlobalAttributeFilter)
+	_ = w.WriteByte('>')
+
+	return ast.WalkSkipChildren, r.math.RenderMath(w, node.(*n_ast.EquationBlock).Expression, true)
+}
+
+func (r *Renderer) renderFileInCell(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString(`</span>`)
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<span style="margin-right:6px">`)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderIcon(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	// icons within links to pages are displayed inline
+	inLink := n.Parent() != nil && n.Parent().Kind() == ast.KindLink
+
+	if !entering {
+		if !inLink {
+			_, _ = w.WriteString(`</div>`)
+		}
+
+		return ast.WalkContinue, nil
+	}
+
+	switch header, _ := n.Parent().(*n_ast.PageHeader); {
+	case header != nil && header.Cover != "":
+		_, _ = w.WriteString(`<div class="page-header-icon page-header-icon-with-cover">`)
+	case header != nil:
+		_, _ = w.WriteString(`<div class="page-header-icon">`)
+	case !inLink:
+		_, _ = w.WriteString(`<div style="font-size:1.5em">`)
+	}
+
+	if emoji := n.(*n_ast.Icon).Emoji; emoji != "" {
+		_, _ = w.WriteString(`<span class="icon">`)
+		_, _ = w.Write(util.EscapeHTML([]byte(emoji)))
+		_, _ = w.WriteString(`</span>`)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderPageHeader(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</header>")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<header>")
+
+	if cover := node.(*n_ast.PageHeader).Cover; cover != "" {
+		_, _ = w.WriteString(`<img class="page-cover-image" src="`)
+		_, _ = w.Write(util.EscapeHTML(safeURL([]byte(cover))))
+		_, _ = w.WriteString(`" style="object-position:center 0%"/>`)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderLinkPreview(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</figure>")
+		return ast.WalkContinue, nil
+	}
+
+	// notion shows the URL without linking to it
+	_, _ = w.WriteString("<figure")
+	html.RenderAttributes(w, n, html.GlobalAttributeFilter)
+	_, _ = w.WriteString(`><div class="source">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.(*n_ast.LinkPreview).URL)))
+	_, _ = w.WriteString(`</div>`)
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderMention(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*n_ast.Mention)
+	m := n.Content
+
+	switch m.Type {
+	case notion.MentionTypeUser:
+		if !entering {
+			_, _ = w.WriteString(`</span>`)
+			return ast.WalkContinue, nil
+		}
+
+		_, _ = w.WriteString(`<span class="user">`)
+
+		if !n.HasChildren() && m.User.Name != nil {
+			_ = w.WriteByte('@')
+			_, _ = w.Write(escapeHTML([]byte(*m.User.Name)))
+		}
+	case notion.MentionTypeDate:
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		// notion always displays mentioned times with a twelve hour clock
+		_, _ = w.WriteString(`<time>`)
+		writeDate(w, m.Date, true)
+		_, _ = w.WriteString(`</time>`)
+
+		return ast.WalkSkipChildren, nil
+	case notion.MentionTypePage, notion.MentionTypeDatabase:
+		// resolved mentions contain the link
+		if n.FirstChild() != nil && n.FirstChild().Kind() == ast.KindLink {
+			return ast.WalkContinue, nil
+		}
+
+		if !entering {
+			_, _ = w.WriteString(`</a>`)
+			return ast.WalkContinue, nil
+		}
+
+		ref := m.Page
+		if m.Type == notion.MentionTypeDatabase {
+			ref = m.Database
+		}
+
+		_, _ = w.WriteString(`<a href="https://www.notion.so/`)
+		_, _ = w.WriteString(strings.ReplaceAll(string(ref.Id), "-", ""))
+		_, _ = w.WriteString(`">`)
+	case notion.MentionTypeLinkPreview:
+		if !entering {
+			_, _ = w.WriteString(`</a>`)
+			return ast.WalkContinue, nil
+		}
+
+		_, _ = w.WriteString(`<a href="`)
+		_, _ = w.Write(util.EscapeHTML(safeURL([]byte(m.LinkPreview.Url))))
+		_, _ = w.WriteString(`">`)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderSelect(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString(`</span>`)
+		return ast.WalkContinue, nil
+	}
+
+	val := node.(*n_ast.Select).Data
+
+	_, _ = w.WriteString(`<span class="selected-value`)
+
+	switch val.Color {
+	case notion.ColorDefault, "": // no color
+	default:
+		_, _ = w.WriteString(` select-value-color-`)
+		_, _ = w.WriteString(string(val.Color))
+	}
+
+	_, _ = w.WriteString(`">`)
+	_, _ = w.Write(escapeHTML([]byte(val.Name)))
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderStatus(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString(`</span>`)
+		return ast.WalkContinue, nil
+	}
+
+	data := node.(*n_ast.Status).Data
+
+	_, _ = w.WriteString(`<span class="status-value select-value-color-`)
+	_, _ = w.WriteString(string(data.Color))
+	_, _ = w.WriteString(`"><div class="status-dot status-dot-color-`)
+	_, _ = w.WriteString(string(data.Color))
+	_, _ = w.WriteString(`"></div>`)
+	_, _ = w.Write(escapeHTML([]byte(data.Name)))
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderToggle(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</details></li></ul>")
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<ul")
+	html.RenderAttributes(w, n, idFilter)
+	renderClass(w, n)
+	_, _ = w.WriteString(`><li><details open="">`)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderUnderline(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<span style="border-bottom:0.05em solid">`)
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("</span>")
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderUnsupported(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*n_ast.Unsupported)
+
+	// rich texts are rendered as plain text
+	if !n.Block {
+		return ast.WalkContinue, nil
+	}
+
+	if entering {
+		_, _ = w.WriteString("<!-- unsupported block type ")
+		_, _ = w.Write(util.EscapeHTML([]byte(n.NotionType)))
+		_, _ = w.WriteString(" -->")
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderUser(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString(`</span>`)
+		return ast.WalkContinue, nil
+	}
+
+	user := node.(*n_ast.User).Data
+
+	_, _ = w.WriteString(`<span class="user">`)
+
+	if user.AvatarUrl != nil {
+		_, _ = w.WriteString(`<img src="`)
+		_, _ = w.Write(escapeHTML(safeURL([]byte(*user.AvatarUrl))))
+		_, _ = w.WriteString(`" class="icon user-icon"/>`)
+	}
+
+	if user.Name != nil {
+		_, _ = w.Write(escapeHTML([]byte(*user.Name)))
+	}
+
+	return ast.WalkContinue, nil
+}
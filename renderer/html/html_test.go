@@ -0,0 +1,146 @@
+package html_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/faetools/notion-to-goldmark/renderer/html"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+)
+
+var r = goldmark.New(goldmark.WithExtensions(html.Notion)).Renderer()
+
+const katexStyle = `<style>@import url('https://cdnjs.cloudflare.com/ajax/libs/KaTeX/0.13.2/katex.min.css')</style>`
+
+func render(t *testing.T, nodes ...ast.Node) string {
+	t.Helper()
+
+	doc := ast.NewDocument()
+	for _, n := range nodes {
+		doc.AppendChild(doc, n)
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, r.Render(buf, nil, doc))
+
+	return buf.String()
+}
+
+func link(dest string) ast.Node {
+	l := ast.NewLink()
+	l.Destination = []byte(dest)
+	l.AppendChild(l, ast.NewString([]byte("click")))
+
+	return l
+}
+
+func TestRenderer_DangerousURLs(t *testing.T) {
+	t.Parallel()
+
+	for _, dest := range []string{"javascript:alert(1)", " JavaScript:alert(1)", "data:text/html,<b>"} {
+		assert.Equal(t, `<a href="">click</a>`, render(t, link(dest)), dest)
+	}
+
+	assert.Equal(t, `<a href="https://example.com/?a=1&amp;b=2">click</a>`,
+		render(t, link("https://example.com/?a=1&b=2")))
+
+	assert.Equal(t, `<figure><a href="" class="bookmark source"><div class="bookmark-info"><div class="bookmark-text">`+
+		`<div class="bookmark-title">javascript:alert(1)</div></div><div class="bookmark-href">javascript:alert(1)</div></div></a></figure>`,
+		render(t, &n_ast.Bookmark{URL: "javascript:alert(1)"}))
+}
+
+func TestRenderer_Icon(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `<div style="font-size:1.5em"><span class="icon">&lt;img src=x onerror=alert(1)&gt;</span></div>`,
+		render(t, &n_ast.Icon{Emoji: "<img src=x onerror=alert(1)>"}))
+}
+
+func TestRenderer_MathStyle(t *testing.T) {
+	t.Parallel()
+
+	p := ast.NewParagraph()
+	p.AppendChild(p, &n_ast.Equation{Expression: "a^2"})
+	p.AppendChild(p, &n_ast.Equation{Expression: "b^2"})
+
+	got := render(t, p, &n_ast.EquationBlock{Expression: "c^2"})
+	assert.Equal(t, 1, strings.Count(got, katexStyle))
+	assert.True(t, strings.HasPrefix(got, katexStyle))
+
+	// without equations, the stylesheet is not needed
+	assert.Equal(t, `<a href="https://example.com">click</a>`, render(t, link("https://example.com")))
+
+	// MathML needs no stylesheet
+	mathML := goldmark.New(goldmark.WithExtensions(html.NewExtender(html.WithMathRenderer(html.MathML)))).Renderer()
+
+	buf := &bytes.Buffer{}
+	doc := ast.NewDocument()
+	doc.AppendChild(doc, &n_ast.EquationBlock{Expression: "c^2"})
+	assert.NoError(t, mathML.Render(buf, nil, doc))
+	assert.NotContains(t, buf.String(), "<style>")
+}
+
+func text(s string) ast.Node { return ast.NewString([]byte(s)) }
+
+func withChildren(n ast.Node, children ...ast.Node) ast.Node {
+	for _, c := range children {
+		n.AppendChild(n, c)
+	}
+
+	return n
+}
+
+func TestRenderer_Nodes(t *testing.T) {
+	t.Parallel()
+
+	date := &notion.Date{Start: time.Date(2022, time.July, 14, 0, 0, 0, 0, time.UTC)}
+	divider := ast.NewThematicBreak()
+	divider.SetAttributeString("id", []byte("divider"))
+
+	for _, tc := range []struct {
+		name string
+		node ast.Node
+		want string
+	}{
+		{"paragraph", withChildren(ast.NewParagraph(), text("Hello")), `<p>Hello</p>`},
+		{"heading", withChildren(ast.NewHeading(2), text("Hello")), `<h2>Hello</h2>`},
+		{"divider", divider, `<hr id="divider"/>`},
+		{"color", withChildren(ast.NewParagraph(), withChildren(&n_ast.Color{Color: notion.ColorRedBackground}, text("red"))),
+			`<p><mark class="highlight-red_background">red</mark></p>`},
+		{"underline", withChildren(ast.NewParagraph(), withChildren(&n_ast.Underline{}, text("under"))),
+			`<p><span style="border-bottom:0.05em solid">under</span></p>`},
+		{"callout", withChildren(&n_ast.Callout{}, &n_ast.Icon{Emoji: "💡"}, withChildren(&n_ast.CalloutText{}, text("Note"))),
+			`<figure><div style="font-size:1.5em"><span class="icon">💡</span></div><div style="width:100%">Note</div></figure>`},
+		{"toggle", withChildren(&n_ast.Toggle{}, withChildren(&n_ast.ToggleText{}, text("More")),
+			withChildren(&n_ast.BlockChildren{}, withChildren(ast.NewParagraph(), text("Hidden")))),
+			`<ul class=""><li><details open=""><summary>More</summary><p>Hidden</p></details></li></ul>`},
+		{"checkbox text", withChildren(&n_ast.CheckboxText{Checked: true}, text("Done")), `<span>Done</span>`},
+		{"columns", withChildren(n_ast.NewColumnList(), withChildren(n_ast.NewColumn(), withChildren(ast.NewParagraph(), text("A")))),
+			`<div><div style="width:100%" class=""><p>A</p></div></div>`},
+		{"equation", withChildren(ast.NewParagraph(), &n_ast.Equation{Expression: "a<b"}),
+			katexStyle + `<p><span data-token-index="0" contenteditable="false" class="notion-text-equation-token" ` +
+				`style="user-select:all;-webkit-user-select:all;-moz-user-select:all"><span></span><span>\(a&lt;b\)</span><span>` + "\ufeff" + `</span></span></p>`},
+		{"equation block", &n_ast.EquationBlock{Expression: "a<b"},
+			katexStyle + `<figure><div class="equation-container"><span class="katex-display">\[a&lt;b\]</span></div></figure>`},
+		{"date", withChildren(ast.NewParagraph(), n_ast.NewDate(date, false)), `<p><time>@July 14, 2022</time></p>`},
+		{"select", withChildren(ast.NewParagraph(), &n_ast.Select{Data: &notion.SelectValue{Name: "tag", Color: notion.ColorBlue}}),
+			`<p><span class="selected-value select-value-color-blue">tag</span></p>`},
+		{"link preview", &n_ast.LinkPreview{URL: "https://github.com/faetools/go-notion"},
+			`<figure><div class="source">https://github.com/faetools/go-notion</div></figure>`},
+		{"unsupported", n_ast.NewUnsupported(true, "breadcrumb"), `<!-- unsupported block type breadcrumb -->`},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, render(t, tc.node))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package html
+
+import (
+	"github.com/faetools/notion-to-goldmark/mathml"
+	"github.com/yuin/goldmark/util"
+)
+
+const katexStyle = `<style>@import url('https://cdnjs.cloudflare.com/ajax/libs/KaTeX/0.13.2/katex.min.css')</style>`
+
+// A MathRenderer renders the LaTeX expressions of equations.
+type MathRenderer interface {
+	// RenderMath writes the expression of an equation.
+	// Display is set for equation blocks, which are already wrapped in a figure.
+	RenderMath(w util.BufWriter, expression string, display bool) error
+}
+
+// MathRendererFunc is an adapter to use a function as a MathRenderer.
+type MathRendererFunc func(w util.BufWriter, expression string, display bool) error
+
+// RenderMath implements MathRenderer.
+func (f MathRendererFunc) RenderMath(w util.BufWriter, expression string, display bool) error {
+	return f(w, expression, display)
+}
+
+// A MathStyler is a MathRenderer whose equations need a stylesheet.
+// Documents with equations start with it.
+type MathStyler interface {
+	MathRenderer
+	// MathStyle returns the HTML that includes the stylesheet.
+	MathStyle() string
+}
+
+// KaTeX renders equations with the markup of Notion's export, but leaves the typesetting
+// to KaTeX's auto-render extension in the browser. The expressions are wrapped
+// in its default delimiters: \(...\) for inline equations and \[...\] for equation blocks.
+// It is a MathStyler that imports KaTeX's stylesheet.
+var KaTeX MathRenderer = katex{}
+
+type katex struct{}
+
+// MathStyle implements MathStyler.
+func (katex) MathStyle() string { return katexStyle }
+
+// RenderMath implements MathRenderer.
+func (katex) RenderMath(w util.BufWriter, expression string, display bool) error {
+	expr := util.EscapeHTML([]byte(expression))
+
+	if display {
+		_, _ = w.WriteString(`<div class="equation-container"><span class="katex-display">\[`)
+		_, _ = w.Write(expr)
+		_, _ = w.WriteString(`\]</span></div>`)
+
+		return nil
+	}
+
+	_, _ = w.WriteString(`<span data-token-index="0" contenteditable="false" class="notion-text-equation-token" style="user-select:all;-webkit-user-select:all;-moz-user-select:all"><span></span><span>\(`)
+	_, _ = w.Write(expr)
+	_, _ = w.WriteString("\\)</span><span>\ufeff</span></span>")
+
+	return nil
+}
+
+// MathML renders equations as MathML, which browsers display without JavaScript.
+// Expressions that cannot be converted are displayed as errors.
+var MathML MathRenderer = MathRendererFunc(func(w util.BufWriter, expression string, display bool) error {
+	math, err := mathml.Convert(expression, display)
+	if err != nil {
+		attrs := ` xmlns="http://www.w3.org/1998/Math/MathML"`
+		if display {
+			attrs += ` display="block"`
+		}
+
+		math = `<math` + attrs + `><merror><mtext>` +
+			string(util.EscapeHTML([]byte(expression))) + `</mtext></merror></math>`
+	}
+
+	if !display {
+		_, _ = w.WriteString(math)
+		return nil
+	}
+
+	_, _ = w.WriteString(`<div class="equation-container">`)
+	_, _ = w.WriteString(math)
+	_, _ = w.WriteString(`</div>`)
+
+	return nil
+})
@@ -0,0 +1,143 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	idFilter      = util.NewBytesFilter([]byte("id"))
+	classFilter   = util.NewBytesFilter([]byte("class"))
+	svgFilter     = html.GlobalAttributeFilter.Extend([]byte("viewBox"))
+	pathFilter    = util.NewBytesFilter([]byte("d"))
+	polygonFilter = util.NewBytesFilter([]byte("points"))
+)
+
+func noop(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
+	return ast.WalkContinue, nil
+}
+
+// escapeHTML escapes text like Notion's export does, which also escapes single quotes.
+func escapeHTML(b []byte) []byte {
+	return bytes.ReplaceAll(util.EscapeHTML(b), []byte("'"), []byte("&#x27;"))
+}
+
+// safeURL returns the URL unless it is dangerous, e.g. a javascript: URL,
+// which is left out like goldmark's HTML renderer does.
+func safeURL(url []byte) []byte {
+	if html.IsDangerousURL(bytes.ToLower(bytes.TrimSpace(url))) {
+		return nil
+	}
+
+	return url
+}
+
+// hasEquations reports whether the node contains equations.
+func hasEquations(n ast.Node) bool {
+	found := false
+
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch n.Kind() {
+		case n_ast.KindEquation, n_ast.KindEquationBlock:
+			found = true
+			return ast.WalkStop, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return found
+}
+
+// renderTag factories out a simple function to render a tag
+func renderTag(tagName string, filter util.BytesFilter) renderer.NodeRendererFunc {
+	start := "<" + tagName
+	end := "</" + tagName + ">"
+
+	return func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(start)
+			html.RenderAttributes(w, n, filter)
+			_ = w.WriteByte('>')
+		} else {
+			_, _ = w.WriteString(end)
+		}
+
+		return ast.WalkContinue, nil
+	}
+}
+
+func formatTime(ts time.Time, twelveHourClock bool) string {
+	if ts.Minute() == 0 && ts.Hour() == 0 {
+		return ts.Format("January 2, 2006")
+	}
+
+	if twelveHourClock {
+		return ts.Local().Format("January 2, 2006 3:04 PM")
+	}
+
+	// we need to remove the zero
+	// e.g. "August 12, 2022 03:00" -> "August 12, 2022 3:00"
+	return strings.Replace(ts.Local().Format("January 2, 2006 15:04"), " 0", " ", 1)
+}
+
+func listDepth(l ast.Node, ordered bool) int {
+	switch p := l.Parent().(type) {
+	case *ast.List:
+		if p.IsOrdered() != ordered {
+			return 0
+		}
+
+		return listDepth(p, ordered) + 1
+	case *n_ast.BlockChildren, *ast.ListItem:
+		return listDepth(p, ordered)
+	default:
+		return 0
+	}
+}
+
+func renderClass(w util.BufWriter, nodes ...ast.Node) {
+	_, _ = w.WriteString(` class="`)
+
+	classes := [][]byte{}
+
+	for _, n := range nodes {
+		cl, _ := n.AttributeString("class")
+		class, _ := cl.([]byte)
+
+		if len(class) == 0 {
+			continue
+		}
+
+		classes = append(classes, class)
+	}
+
+	_, _ = w.Write(bytes.Join(classes, []byte{' '}))
+	_ = w.WriteByte('"')
+}
+
+func numPrevious(n ast.Node) int {
+	prev := n.PreviousSibling()
+	if prev == nil {
+		return 0
+	}
+
+	return numPrevious(prev) + 1
+}
+
+func hasAncestor(n ast.Node, kind ast.NodeKind) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == kind {
+			return true
+		}
+	}
+
+	return false
+}
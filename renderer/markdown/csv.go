@@ -0,0 +1,133 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/csv"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// byteOrderMark is written at the beginning of each CSV file, like Notion does.
+const byteOrderMark = "\ufeff"
+
+// writeCSV writes the table of the child database as a CSV file next to the page.
+// Databases shown in a layout other than a table have no CSV file.
+func (r *Renderer) writeCSV(source []byte, n *n_ast.ChildDatabase) error {
+	var table *extast.Table
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *extast.Table:
+			table = c
+		case *n_ast.DatabaseView:
+			if t, ok := c.FirstChild().(*extast.Table); ok {
+				table = t
+			}
+		}
+	}
+
+	if table == nil {
+		return nil
+	}
+
+	p, err := url.PathUnescape(n.Path + ".csv")
+	if err != nil {
+		return err
+	}
+
+	// links in the CSV are relative to the directory of the CSV file
+	dir := path.Dir(n.Path) + "/"
+
+	buf := bytes.NewBufferString(byteOrderMark)
+	cw := csv.NewWriter(buf)
+
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		record := []string{}
+
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			isTitle := cell == row.FirstChild()
+			record = append(record, cellValue(source, cell, isTitle, dir))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		return err
+	}
+
+	if err := r.fs.MkdirAll(path.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(r.fs, p, buf.Bytes(), 0o644)
+}
+
+// cellValue returns the value of a table cell as Notion writes it in its CSV export.
+func cellValue(source []byte, cell ast.Node, isTitle bool, dir string) string {
+	b := &strings.Builder{}
+
+	for c := cell.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c.Kind() {
+		case n_ast.KindFileInCell, n_ast.KindSelect, n_ast.KindUser:
+			// lists of values are separated by commas
+			if prev := c.PreviousSibling(); prev != nil && prev.Kind() == c.Kind() {
+				b.WriteString(", ")
+			}
+		}
+
+		_ = ast.Walk(c, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+
+			switch n := node.(type) {
+			case *ast.String:
+				b.Write(n.Value)
+			case *ast.Text:
+				b.Write(n.Segment.Value(source))
+			case *ast.Link:
+				// the title links to the entry, but only the title is exported
+				if isTitle {
+					return ast.WalkContinue, nil
+				}
+
+				b.WriteString(strings.TrimPrefix(destination(n.Destination), dir))
+			case *extast.TaskCheckBox:
+				if n.IsChecked {
+					b.WriteString("Yes")
+				} else {
+					b.WriteString("No")
+				}
+			case *n_ast.Date:
+				b.WriteString(formatDate(n.Date, n.TwelveHourClock))
+			case *n_ast.Select:
+				b.WriteString(n.Data.Name)
+			case *n_ast.Status:
+				b.WriteString(html.UnescapeString(n.Data.Name))
+			case *n_ast.User:
+				if n.Data.Name != nil {
+					b.WriteString(*n.Data.Name)
+				}
+			case *n_ast.PropertyIcon:
+			default:
+				return ast.WalkContinue, nil
+			}
+
+			return ast.WalkSkipChildren, nil
+		})
+	}
+
+	return b.String()
+}
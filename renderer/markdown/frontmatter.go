@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"github.com/pelletier/go-toml"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+	"gopkg.in/yaml.v2"
+)
+
+// FrontMatterFormat is the format of the front matter.
+type FrontMatterFormat int
+
+const (
+	// YAML front matter is delimited by "---", like goldmark-meta expects.
+	YAML FrontMatterFormat = iota
+	// TOML front matter is delimited by "+++", like Hugo expects.
+	TOML
+)
+
+// WithFrontMatterFormat sets the format of the front matter. The default is YAML.
+func WithFrontMatterFormat(f FrontMatterFormat) Option {
+	return func(r *Renderer) { r.frontMatter = f }
+}
+
+// renderFrontMatter writes the metadata of the document as front matter, if there is any.
+func (r *Renderer) renderFrontMatter(w util.BufWriter, doc *ast.Document) error {
+	meta := doc.Meta()
+	if len(meta) == 0 {
+		return nil
+	}
+
+	delim, marshal := "---\n", yaml.Marshal
+	if r.frontMatter == TOML {
+		delim, marshal = "+++\n", toml.Marshal
+	}
+
+	b, err := marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	r.write(w, delim)
+	r.write(w, string(b))
+	r.write(w, delim)
+
+	// separate the content from the front matter
+	r.level().started = true
+
+	return nil
+}
@@ -0,0 +1,764 @@
+// Package markdown renders the nodes of a converted Notion page as
+// GitHub Flavored Markdown, reproducing Notion's "Markdown & CSV" export.
+package markdown
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Option configures the Renderer.
+type Option func(*Renderer)
+
+// WithFS sets the file system that child databases are written to as CSV files,
+// next to the Markdown file. Without it, child databases are only linked.
+func WithFS(fs afero.Fs) Option {
+	return func(r *Renderer) { r.fs = fs }
+}
+
+// Renderer is a renderer.NodeRenderer that renders Notion nodes
+// the way Notion's Markdown export does.
+//
+// The Renderer keeps state while rendering, so it must not be used concurrently.
+type Renderer struct {
+	fs          afero.Fs
+	frontMatter FrontMatterFormat
+
+	prefixes    []string
+	atLineStart bool
+	hasText     bool   // whether text has been written in the current line
+	pending     string // trailing whitespace that is only written if more text follows
+	levels      []*level
+	marks       []mark
+	inCell      bool // whether a table cell is being rendered
+}
+
+// NewRenderer returns a new Renderer.
+func NewRenderer(opts ...Option) renderer.NodeRenderer {
+	r := &Renderer{}
+	r.reset()
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// New returns a renderer.Renderer that only renders Markdown.
+func New(opts ...Option) renderer.Renderer {
+	return renderer.NewRenderer(renderer.WithNodeRenderers(
+		util.Prioritized(NewRenderer(opts...), 100),
+	))
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.
+func (r *Renderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	// goldmark nodes
+	reg.Register(ast.KindDocument, r.renderDocument)
+	reg.Register(ast.KindParagraph, r.renderParagraph)
+	reg.Register(ast.KindHeading, r.renderHeading)
+	reg.Register(ast.KindBlockquote, r.renderBlockquote)
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+	reg.Register(ast.KindThematicBreak, r.renderThematicBreak)
+	reg.Register(ast.KindList, r.renderList)
+	reg.Register(ast.KindListItem, r.renderListItem)
+	reg.Register(ast.KindText, r.renderText)
+	reg.Register(ast.KindString, r.renderString)
+	reg.Register(ast.KindEmphasis, noop)
+	reg.Register(ast.KindCodeSpan, noop)
+	reg.Register(ast.KindLink, r.renderLink)
+	reg.Register(ast.KindImage, r.renderImage)
+
+	reg.Register(extast.KindStrikethrough, noop)
+	reg.Register(extast.KindTaskCheckBox, r.renderTaskCheckBox)
+	reg.Register(extast.KindTable, r.renderTable)
+	reg.Register(extast.KindTableHeader, r.renderTableHeader)
+	reg.Register(extast.KindTableRow, r.renderTableRow)
+	reg.Register(extast.KindTableCell, r.renderTableCell)
+
+	// notion nodes
+	reg.Register(n_ast.KindBlockChildren, r.renderBlockChildren)
+	reg.Register(n_ast.KindBookmark, r.renderBookmark)
+	reg.Register(n_ast.KindCallout, r.renderCallout)
+	reg.Register(n_ast.KindCalloutText, noop)
+	reg.Register(n_ast.KindCaption, r.renderCaption)
+	reg.Register(n_ast.KindCheckboxText, noop)
+	reg.Register(n_ast.KindChildDatabase, r.renderChildDatabase)
+	reg.Register(n_ast.KindChildPage, r.renderBlock)
+	reg.Register(n_ast.KindChildren, noop)
+	reg.Register(n_ast.KindColor, noop)
+	reg.Register(n_ast.KindColumn, noop) // columns are rendered one after another
+	reg.Register(n_ast.KindColumnList, noop)
+	reg.Register(n_ast.KindDatabaseCard, r.renderListItem)
+	reg.Register(n_ast.KindDatabaseCardProperty, r.renderDatabaseCardProperty)
+	reg.Register(n_ast.KindDatabaseGroup, r.renderDatabaseGroup)
+	reg.Register(n_ast.KindDatabaseView, r.renderList) // the cards or groups are listed
+	reg.Register(n_ast.KindDate, r.renderDate)
+	reg.Register(n_ast.KindEmbed, r.renderEmbed)
+	reg.Register(n_ast.KindEmbedSource, skip)
+	reg.Register(n_ast.KindEquation, r.renderEquation)
+	reg.Register(n_ast.KindEquationBlock, r.renderEquationBlock)
+	reg.Register(n_ast.KindFile, r.renderFile)
+	reg.Register(n_ast.KindFileInCell, noop)
+	reg.Register(n_ast.KindIcon, r.renderIcon)
+	reg.Register(n_ast.KindLinkPreview, r.renderLinkPreview)
+	reg.Register(n_ast.KindLinkToPage, r.renderBlock)
+	reg.Register(n_ast.KindMention, r.renderMention)
+	reg.Register(n_ast.KindPageHeader, noop) // only the title is exported
+	reg.Register(n_ast.KindPolygon, skip)
+	reg.Register(n_ast.KindPropertyIcon, skip)
+	reg.Register(n_ast.KindSelect, r.renderSelect)
+	reg.Register(n_ast.KindStatus, r.renderStatus)
+	reg.Register(n_ast.KindSVG, skip)
+	reg.Register(n_ast.KindSVGPath, skip)
+	reg.Register(n_ast.KindSyncedBlock, r.renderBlock)
+	reg.Register(n_ast.KindTableOfContents, skip) // notion does not export the table of contents
+	reg.Register(n_ast.KindToggle, r.renderListItem)
+	reg.Register(n_ast.KindToggleText, noop)
+	reg.Register(n_ast.KindUnderline, noop) // markdown does not know underlines
+	reg.Register(n_ast.KindUnsupported, r.renderUnsupported)
+	reg.Register(n_ast.KindUser, r.renderUser)
+	reg.Register(n_ast.KindVideo, r.renderBlock)
+}
+
+func noop(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
+	return ast.WalkContinue, nil
+}
+
+func skip(util.BufWriter, []byte, ast.Node, bool) (ast.WalkStatus, error) {
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderDocument(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.reset()
+
+		if err := r.renderFrontMatter(w, n.(*ast.Document)); err != nil {
+			return ast.WalkStop, err
+		}
+	} else {
+		r.endLine(w)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+// renderBlock renders a block whose children render themselves.
+// Blocks without children are ignored.
+func (r *Renderer) renderBlock(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !n.HasChildren() {
+		return ast.WalkSkipChildren, nil
+	}
+
+	if entering {
+		r.beginBlock(w, n)
+	} else {
+		r.endLine(w)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderParagraph(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	// notion drops empty paragraphs
+	return r.renderBlock(w, source, n, entering)
+}
+
+func (r *Renderer) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		return ast.WalkContinue, nil
+	}
+
+	r.beginBlock(w, node)
+	r.write(w, strings.Repeat("#", node.(*ast.Heading).Level)+" ")
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderBlockquote(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.beginBlock(w, n)
+		r.pushPrefix("> ")
+
+		return ast.WalkContinue, nil
+	}
+
+	// notion ends quotes with an empty line
+	r.blankLine(w)
+	r.popPrefix()
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.FencedCodeBlock)
+
+	r.beginBlock(w, n)
+	r.write(w, "```")
+	r.write(w, string(n.Language(source)))
+	r.write(w, "\n")
+
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		r.write(w, string(line.Value(source)))
+	}
+
+	r.endLine(w)
+	r.write(w, "```\n")
+
+	// notion ignores the caption of fenced code blocks
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderThematicBreak(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.beginBlock(w, n)
+		r.write(w, "---\n")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderList(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.beginBlock(w, n)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderListItem(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		r.popPrefix()
+
+		return ast.WalkContinue, nil
+	}
+
+	r.endLine(w)
+
+	if l, ok := n.Parent().(*ast.List); ok && l.IsOrdered() {
+		r.write(w, strconv.Itoa(numPrevious(n)+1)+". ")
+	} else {
+		r.write(w, "- ")
+	}
+
+	r.pushPrefix("    ")
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderText(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Text)
+
+	r.syncMarks(w, marksOf(n))
+	r.writeText(w, string(n.Segment.Value(source)))
+
+	switch {
+	case !n.SoftLineBreak() && !n.HardLineBreak():
+	case r.inCell:
+		// a new line would end the row
+		r.write(w, "<br>")
+	default:
+		r.write(w, "\n")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderString(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.syncMarks(w, marksOf(n))
+		r.writeText(w, string(n.(*ast.String).Value))
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.write(w, "]("+destination(node.(*ast.Link).Destination)+")")
+		return ast.WalkContinue, nil
+	}
+
+	// annotations within the link are closed after the link
+	r.syncMarks(w, marksOf(node))
+	r.write(w, "[")
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Image)
+
+	r.syncMarks(w, marksOf(n))
+	r.write(w, "!["+string(n.Text(source))+"]("+destination(n.Destination)+")")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderTaskCheckBox(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	// notion separates the checkbox with two spaces from the text
+	if node.(*extast.TaskCheckBox).IsChecked {
+		r.write(w, "[x]  ")
+	} else {
+		r.write(w, "[ ]  ")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTable(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.beginBlock(w, n)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTableHeader(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	return r.renderTableRow(w, source, n, entering)
+}
+
+func (r *Renderer) renderTableRow(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.write(w, "|")
+		return ast.WalkContinue, nil
+	}
+
+	r.endLine(w)
+
+	// tables need a header in GFM, so without one the first row is used
+	if n.PreviousSibling() == nil {
+		r.write(w, "|"+strings.Repeat(" --- |", n.ChildCount())+"\n")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderTableCell(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	r.inCell = entering
+
+	if entering {
+		r.write(w, " ")
+	} else {
+		r.syncMarks(w, 0)
+		r.write(w, " |")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderBlockChildren(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	inList := false
+
+	switch n.Parent().Kind() {
+	case ast.KindListItem, n_ast.KindToggle:
+		inList = true
+	}
+
+	if entering {
+		switch n.Parent().Kind() {
+		case n_ast.KindSyncedBlock:
+			// the children are the only content of a synced block
+			r.pushLevel(&level{})
+		default:
+			// the children follow the text of their parent
+			r.endLine(w)
+			r.pushLevel(&level{started: true, lastWasList: inList})
+		}
+
+		return ast.WalkContinue, nil
+	}
+
+	if l := r.popLevel(); inList && !l.lastWasList {
+		// notion adds an empty line after content in a list item
+		r.blankLine(w)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderBookmark(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		return ast.WalkContinue, nil
+	}
+
+	r.beginBlock(w, node)
+
+	n := node.(*n_ast.Bookmark)
+
+	// without metadata, notion uses the URL as title
+	title := n.URL
+	if n.Title != "" {
+		title = n.Title
+	}
+
+	r.writeLink(w, title, n.URL)
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderCallout(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.beginBlock(w, n)
+		r.write(w, "<aside>\n")
+	} else {
+		r.blankLine(w)
+		r.write(w, "</aside>\n")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderCaption(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	// the caption follows as its own paragraph
+	if entering {
+		r.blankLine(w)
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderChildDatabase(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*n_ast.ChildDatabase)
+
+	// databases in other layouts have no CSV file, so their title and cards are rendered instead
+	if v, ok := n.LastChild().(*n_ast.DatabaseView); ok && v.Layout != n_ast.DatabaseLayoutTable {
+		return ast.WalkContinue, nil
+	}
+
+	r.beginBlock(w, n)
+	r.writeLink(w, n.Title, n.Path+".csv")
+
+	if r.fs != nil {
+		if err := r.writeCSV(source, n); err != nil {
+			return ast.WalkStop, err
+		}
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+// renderDatabaseGroup lists the cards of the group under its name.
+func (r *Renderer) renderDatabaseGroup(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if _, err := r.renderListItem(w, source, node, entering); err != nil || !entering {
+		return ast.WalkContinue, err
+	}
+
+	n := node.(*n_ast.DatabaseGroup)
+
+	if n.Value != nil {
+		r.write(w, n.Value.Name)
+	} else {
+		r.write(w, n.Day.Format("January 2, 2006"))
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderDatabaseCardProperty(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.write(w, " · ")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderDate(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		n := node.(*n_ast.Date)
+
+		r.syncMarks(w, marksOf(n))
+		r.writeText(w, formatDate(n.Date, n.TwelveHourClock))
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderEmbed(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		return ast.WalkContinue, nil
+	}
+
+	link, ok := n.FirstChild().FirstChild().(*ast.Link)
+	if !ok {
+		return ast.WalkSkipChildren, nil
+	}
+
+	// the caption is used as the text of the link
+	text := link.Text(source)
+	if c, ok := n.LastChild().(*n_ast.Caption); ok {
+		text = c.Text(source)
+	}
+
+	r.beginBlock(w, n)
+	r.writeLink(w, string(text), destination(link.Destination))
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderFile(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*n_ast.File)
+
+	// like embeds, the caption is used as the text of the link
+	text := n.Name
+	if c, ok := n.LastChild().(*n_ast.Caption); ok {
+		text = string(c.Text(source))
+	} else if n.External {
+		text = string(n.Destination())
+	}
+
+	r.beginBlock(w, n)
+
+	// only uploaded images are shown as images
+	if n.IsImage() && !n.External {
+		r.write(w, "!")
+	}
+
+	r.writeLink(w, text, destination(n.Destination()))
+
+	// Notion repeats the caption of images and PDFs as a paragraph, but not of other files
+	if !n.IsImage() && !n.IsPDF() {
+		return ast.WalkSkipChildren, nil
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *Renderer) renderEquation(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.syncMarks(w, marksOf(node))
+		r.write(w, "$"+node.(*n_ast.Equation).Expression+"$")
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderEquationBlock(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.beginBlock(w, node)
+		r.write(w, "$$\n"+node.(*n_ast.EquationBlock).Expression+"\n$$\n")
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderIcon(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*n_ast.Icon)
+
+	// notion leaves out the icons of linked pages and of the page itself
+	if p := n.Parent(); p != nil && (p.Kind() == ast.KindLink || p.Kind() == n_ast.KindPageHeader) {
+		return ast.WalkSkipChildren, nil
+	}
+
+	if n.Emoji != "" {
+		r.write(w, n.Emoji+" ")
+		return ast.WalkSkipChildren, nil
+	}
+
+	if img, ok := n.FirstChild().(*ast.Image); ok {
+		src := destination(img.Destination)
+		r.write(w, `<img src="`+src+`" alt="`+src+`" width="40px" /> `)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderLinkPreview(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		r.endLine(w)
+		return ast.WalkContinue, nil
+	}
+
+	r.beginBlock(w, node)
+
+	u := node.(*n_ast.LinkPreview).URL
+	r.writeLink(w, u, u)
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderMention(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	m := node.(*n_ast.Mention).Content
+
+	switch m.Type {
+	case notion.MentionTypeDate:
+		if entering {
+			r.syncMarks(w, marksOf(node))
+			// notion always displays mentioned times with a twelve hour clock
+			r.writeText(w, formatDate(m.Date, true))
+		}
+
+		return ast.WalkSkipChildren, nil
+	case notion.MentionTypePage, notion.MentionTypeDatabase:
+		// resolved mentions contain the link
+		if n := node.FirstChild(); n != nil && n.Kind() == ast.KindLink {
+			return ast.WalkContinue, nil
+		}
+
+		if !entering {
+			r.write(w, "]("+mentionURL(m)+")")
+			return ast.WalkContinue, nil
+		}
+
+		r.syncMarks(w, marksOf(node))
+		r.write(w, "[")
+	case notion.MentionTypeLinkPreview:
+		if !entering {
+			r.write(w, "]("+m.LinkPreview.Url+")")
+			return ast.WalkContinue, nil
+		}
+
+		r.syncMarks(w, marksOf(node))
+		r.write(w, "[")
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func mentionURL(m *notion.Mention) string {
+	ref := m.Page
+	if m.Type == notion.MentionTypeDatabase {
+		ref = m.Database
+	}
+
+	return "https://www.notion.so/" + strings.ReplaceAll(string(ref.Id), "-", "")
+}
+
+func (r *Renderer) renderSelect(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.writeText(w, node.(*n_ast.Select).Data.Name)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderStatus(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.writeText(w, node.(*n_ast.Status).Data.Name)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderUnsupported(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*n_ast.Unsupported)
+
+	// rich texts are rendered as plain text
+	if !n.Block {
+		return ast.WalkContinue, nil
+	}
+
+	if entering {
+		r.beginBlock(w, n)
+		r.write(w, "<!-- unsupported block type "+n.NotionType+" -->")
+		r.endLine(w)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *Renderer) renderUser(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if name := node.(*n_ast.User).Data.Name; name != nil {
+			r.writeText(w, *name)
+		}
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+// destination returns the destination of a link in the Markdown export.
+// Exported pages are linked as Markdown files instead of HTML files.
+func destination(dest []byte) string {
+	s := string(dest)
+
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "" || !strings.HasSuffix(u.Path, ".html") {
+		return s
+	}
+
+	return strings.TrimSuffix(s, ".html") + ".md"
+}
+
+func numPrevious(n ast.Node) int {
+	prev := n.PreviousSibling()
+	if prev == nil {
+		return 0
+	}
+
+	return numPrevious(prev) + 1
+}
+
+func formatDate(d *notion.Date, twelveHourClock bool) string {
+	if d == nil {
+		return ""
+	}
+
+	s := formatTime(d.Start, twelveHourClock)
+
+	if d.End != nil {
+		s += " → " + formatTime(*d.End, twelveHourClock)
+	}
+
+	return s
+}
+
+func formatTime(ts time.Time, twelveHourClock bool) string {
+	if ts.Minute() == 0 && ts.Hour() == 0 {
+		return ts.Format("January 2, 2006")
+	}
+
+	if twelveHourClock {
+		return ts.Local().Format("January 2, 2006 3:04 PM")
+	}
+
+	// we need to remove the zero
+	// e.g. "August 12, 2022 03:00" -> "August 12, 2022 3:00"
+	return strings.Replace(ts.Local().Format("January 2, 2006 15:04"), " 0", " ", 1)
+}
@@ -0,0 +1,114 @@
+package markdown_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	n_ast "github.com/faetools/notion-to-goldmark/ast"
+	"github.com/faetools/notion-to-goldmark/renderer/markdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+func render(t *testing.T, nodes ...ast.Node) string {
+	t.Helper()
+
+	doc := ast.NewDocument()
+	for _, n := range nodes {
+		doc.AppendChild(doc, n)
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, markdown.New().Render(buf, nil, doc))
+
+	return buf.String()
+}
+
+func row(cells ...ast.Node) ast.Node {
+	r := extast.NewTableRow(nil)
+
+	for _, c := range cells {
+		cell := extast.NewTableCell()
+		cell.AppendChild(cell, c)
+		r.AppendChild(r, cell)
+	}
+
+	return r
+}
+
+func TestRenderer_Escaping(t *testing.T) {
+	t.Parallel()
+
+	name := "Ann|Bob"
+
+	table := extast.NewTable()
+	table.AppendChild(table, row(
+		&n_ast.Select{Data: &notion.SelectValue{Name: "to|do"}},
+		&n_ast.Status{Data: &notion.SelectValue{Name: "in|progress"}},
+		&n_ast.User{Data: notion.User{Name: &name}},
+	))
+
+	assert.Equal(t, "| to\\|do | in\\|progress | Ann\\|Bob |\n| --- | --- | --- |\n", render(t, table))
+
+	assert.Equal(t, "[\\[x\\] done \\\\](https://example.com)\n",
+		render(t, &n_ast.Bookmark{URL: "https://example.com", Title: `[x] done \`}))
+}
+
+func text(s string) ast.Node { return ast.NewString([]byte(s)) }
+
+func withChildren(n ast.Node, children ...ast.Node) ast.Node {
+	for _, c := range children {
+		n.AppendChild(n, c)
+	}
+
+	return n
+}
+
+func TestRenderer_Nodes(t *testing.T) {
+	t.Parallel()
+
+	date := &notion.Date{Start: time.Date(2022, time.July, 14, 0, 0, 0, 0, time.UTC)}
+
+	for _, tc := range []struct {
+		name string
+		node ast.Node
+		want string
+	}{
+		{"paragraph", withChildren(ast.NewParagraph(), text("Hello")), "Hello\n"},
+		{"heading", withChildren(ast.NewHeading(2), text("Hello")), "## Hello\n"},
+		{"divider", ast.NewThematicBreak(), "---\n"},
+		{"color", withChildren(ast.NewParagraph(), withChildren(&n_ast.Color{Color: notion.ColorRed}, text("red"))),
+			"red\n"},
+		{"underline", withChildren(ast.NewParagraph(), withChildren(&n_ast.Underline{}, text("under"))), "under\n"},
+		{"callout", withChildren(&n_ast.Callout{}, &n_ast.Icon{Emoji: "💡"}, withChildren(&n_ast.CalloutText{}, text("Note"))),
+			"<aside>\n💡 Note\n\n</aside>\n"},
+		{"toggle", withChildren(&n_ast.Toggle{}, withChildren(&n_ast.ToggleText{}, text("More")),
+			withChildren(&n_ast.BlockChildren{}, withChildren(ast.NewParagraph(), text("Hidden")))),
+			"- More\n    \n    Hidden\n    \n"},
+		{"equation", withChildren(ast.NewParagraph(), text("So "), &n_ast.Equation{Expression: "a<b"}, text(" holds.")),
+			"So $a<b$ holds.\n"},
+		{"equation block", &n_ast.EquationBlock{Expression: "a<b"}, "$$\na<b\n$$\n"},
+		{"date", withChildren(ast.NewParagraph(), n_ast.NewDate(date, false), text(" is the day.")),
+			"July 14, 2022 is the day.\n"},
+		{"select", withChildren(ast.NewParagraph(), &n_ast.Select{Data: &notion.SelectValue{Name: "tag", Color: notion.ColorBlue}}),
+			"tag\n"},
+		{"link preview", &n_ast.LinkPreview{URL: "https://github.com/faetools/go-notion"},
+			"[https://github.com/faetools/go-notion](https://github.com/faetools/go-notion)\n"},
+		{"child database", &n_ast.ChildDatabase{Title: "Tasks", Path: "Tasks%20123"}, "[Tasks](Tasks%20123.csv)\n"},
+		{"columns", withChildren(n_ast.NewColumnList(),
+			withChildren(n_ast.NewColumn(), withChildren(ast.NewParagraph(), text("A"))),
+			withChildren(n_ast.NewColumn(), withChildren(ast.NewParagraph(), text("B")))), "A\n\nB\n"},
+		{"unsupported", n_ast.NewUnsupported(true, "breadcrumb"), "<!-- unsupported block type breadcrumb -->\n"},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, render(t, tc.node))
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// level keeps track of the blocks rendered in a container,
+// e.g. the document or the children of a block.
+type level struct {
+	// started is set once something has been rendered in the container
+	started bool
+	// lastWasList is set if the last block in the container was a list
+	lastWasList bool
+}
+
+// mark is an annotation that is written with a delimiter.
+type mark int
+
+const (
+	markBold mark = 1 << iota
+	markItalic
+	markStrikethrough
+	markCode
+)
+
+// marks in the order they are opened
+var allMarks = []mark{markBold, markItalic, markStrikethrough, markCode}
+
+func (m mark) delimiter() string {
+	switch m {
+	case markBold:
+		return "**"
+	case markItalic:
+		return "*"
+	case markStrikethrough:
+		return "~~"
+	default:
+		return "`"
+	}
+}
+
+func (r *Renderer) reset() {
+	r.prefixes = nil
+	r.atLineStart = true
+	r.hasText = false
+	r.pending = ""
+	r.levels = []*level{{}}
+	r.marks = nil
+	r.inCell = false
+}
+
+// write writes s, prefixing every line with the current indentation.
+func (r *Renderer) write(w util.BufWriter, s string) {
+	if r.pending != "" {
+		s = r.pending + s
+		r.pending = ""
+	}
+
+	r.writeRaw(w, s)
+}
+
+// cellEscaper escapes text that would otherwise end a table cell or row.
+var cellEscaper = strings.NewReplacer("|", `\|`, "\n", "<br>")
+
+// writeText writes the text of a leaf node.
+// Like Notion, we trim whitespace at the beginning and end of each line.
+func (r *Renderer) writeText(w util.BufWriter, s string) {
+	if r.inCell {
+		s = cellEscaper.Replace(s)
+	}
+
+	if !r.hasText {
+		s = strings.TrimLeft(s, " ")
+	}
+
+	s = r.pending + s
+	trimmed := strings.TrimRight(s, " ")
+	r.pending = s[len(trimmed):]
+
+	if trimmed != "" {
+		r.hasText = true
+		r.writeRaw(w, trimmed)
+	}
+}
+
+// linkTextEscaper escapes text that would otherwise end the text of a link.
+var linkTextEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+
+// writeLink writes a link with a plain text, e.g. the title of a bookmark.
+func (r *Renderer) writeLink(w util.BufWriter, text, dest string) {
+	r.write(w, "[")
+	r.writeText(w, linkTextEscaper.Replace(text))
+	r.write(w, "]("+dest+")")
+}
+
+func (r *Renderer) writeRaw(w util.BufWriter, s string) {
+	for len(s) > 0 {
+		if r.atLineStart {
+			for _, p := range r.prefixes {
+				_, _ = w.WriteString(p)
+			}
+
+			r.atLineStart = false
+		}
+
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			_, _ = w.WriteString(s)
+			return
+		}
+
+		_, _ = w.WriteString(s[:i+1])
+		r.atLineStart = true
+		s = s[i+1:]
+	}
+}
+
+// endLine closes all open annotations and finishes the current line.
+func (r *Renderer) endLine(w util.BufWriter) {
+	r.pending = ""
+	r.syncMarks(w, 0)
+
+	if !r.atLineStart {
+		r.write(w, "\n")
+	}
+
+	r.hasText = false
+}
+
+// blankLine finishes the current line and writes an empty one.
+func (r *Renderer) blankLine(w util.BufWriter) {
+	r.endLine(w)
+	r.write(w, "\n")
+}
+
+func (r *Renderer) pushPrefix(p string) { r.prefixes = append(r.prefixes, p) }
+func (r *Renderer) popPrefix()          { r.prefixes = r.prefixes[:len(r.prefixes)-1] }
+
+func (r *Renderer) pushLevel(l *level) { r.levels = append(r.levels, l) }
+
+func (r *Renderer) popLevel() *level {
+	l := r.level()
+	r.levels = r.levels[:len(r.levels)-1]
+
+	return l
+}
+
+func (r *Renderer) level() *level {
+	if len(r.levels) == 0 {
+		r.reset()
+	}
+
+	return r.levels[len(r.levels)-1]
+}
+
+// beginBlock separates a new block from the previous one.
+// Notion does not separate lists from each other.
+func (r *Renderer) beginBlock(w util.BufWriter, n ast.Node) {
+	l := r.level()
+	isList := n.Kind() == ast.KindList
+
+	if l.started && !(isList && l.lastWasList) {
+		r.blankLine(w)
+	} else {
+		r.endLine(w)
+	}
+
+	l.started = true
+	l.lastWasList = isList
+}
+
+// syncMarks closes all open annotations that are not wanted anymore
+// and opens the wanted ones that are not open yet.
+//
+// Like Notion, we do not nest the delimiters, e.g. `**a ~~b** c~~` is fine.
+func (r *Renderer) syncMarks(w util.BufWriter, want mark) {
+	for i := len(r.marks) - 1; i >= 0; i-- {
+		m := r.marks[i]
+		if want&m != 0 {
+			continue
+		}
+
+		r.write(w, m.delimiter())
+		r.marks = append(r.marks[:i], r.marks[i+1:]...)
+	}
+
+	var open mark
+	for _, m := range r.marks {
+		open |= m
+	}
+
+	for _, m := range allMarks {
+		if want&m == 0 || open&m != 0 {
+			continue
+		}
+
+		r.write(w, m.delimiter())
+		r.marks = append(r.marks, m)
+	}
+}
+
+// marksOf returns the annotations the ancestors of the node represent.
+func marksOf(n ast.Node) (ms mark) {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		switch p := p.(type) {
+		case *ast.Emphasis:
+			if p.Level == 2 {
+				ms |= markBold
+			} else {
+				ms |= markItalic
+			}
+		case *extast.Strikethrough:
+			ms |= markStrikethrough
+		case *ast.CodeSpan:
+			ms |= markCode
+		case *ast.FencedCodeBlock:
+			// everything is code already
+			return 0
+		}
+	}
+
+	return ms
+}
@@ -0,0 +1,241 @@
+// Package serve previews Notion pages by converting and rendering them as HTML on demand.
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/faetools/go-notion/pkg/notion"
+	"github.com/faetools/notion-to-goldmark/cache"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/renderer/html"
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+)
+
+// AssetsPrefix is the path the downloaded assets are served at.
+const AssetsPrefix = "/assets/"
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithConverterOptions configures the converter of the pages.
+// The link resolver and asset store are set by the Handler.
+func WithConverterOptions(opts ...n_goldmark.Option) Option {
+	return func(h *Handler) { h.opts = append(h.opts, opts...) }
+}
+
+// WithHTMLOptions configures the renderer of the pages.
+func WithHTMLOptions(opts ...html.Option) Option {
+	return func(h *Handler) { h.htmlOpts = append(h.htmlOpts, opts...) }
+}
+
+// WithCache sets the file system pages, databases and the children of pages and blocks are cached in.
+// By default, they are cached in memory.
+func WithCache(fs afero.Fs) Option {
+	return func(h *Handler) { h.cache = fs }
+}
+
+// WithAssets sets the file system the assets are downloaded to.
+// By default, they are kept in memory.
+func WithAssets(fs afero.Fs) Option {
+	return func(h *Handler) { h.assets = fs }
+}
+
+// WithHTTPClient sets the client that downloads the assets.
+// By default, http.DefaultClient is used.
+func WithHTTPClient(cli *http.Client) Option {
+	return func(h *Handler) { h.httpClient = cli }
+}
+
+// Handler is an http.Handler that serves Notion pages and databases as HTML at "/<id>",
+// with or without dashes in the ID.
+//
+// The pages are converted whenever they are requested, so edits show up when reloading them.
+// Since Notion updates when a page was last edited whenever its content changes,
+// only the page itself is fetched again if nothing changed; its children are cached.
+// Since that time is rounded to the minute, pages edited within the last minute are fetched completely.
+//
+// Links to other pages and databases point to their routes on the Handler.
+// Assets are downloaded once and served at AssetsPrefix.
+type Handler struct {
+	cli        notion.Getter
+	opts       []n_goldmark.Option
+	htmlOpts   []html.Option
+	cache      afero.Fs
+	assets     afero.Fs
+	httpClient *http.Client
+
+	renderer renderer.Renderer
+	store    *n_goldmark.FSAssetStore
+	files    http.Handler
+}
+
+// NewHandler returns a new Handler that fetches the pages with cli.
+func NewHandler(cli notion.Getter, opts ...Option) *Handler {
+	h := &Handler{cli: cli}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.cache == nil {
+		h.cache = afero.NewMemMapFs()
+	}
+
+	if h.assets == nil {
+		h.assets = afero.NewMemMapFs()
+	}
+
+	h.renderer = goldmark.New(goldmark.WithExtensions(html.NewExtender(h.htmlOpts...))).Renderer()
+	h.store = n_goldmark.NewFSAssetStore(h.assets, h.httpClient)
+	h.files = http.StripPrefix(AssetsPrefix, http.FileServer(http.FS(afero.NewIOFS(h.assets))))
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, AssetsPrefix) {
+		h.files.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	id, ok := parseID(strings.TrimPrefix(r.URL.Path, "/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	b, err := h.render(r.Context(), id)
+
+	switch {
+	case n_goldmark.IsNotFound(err):
+		http.NotFound(w, r)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(b)
+	}
+}
+
+// render converts and renders the page or database.
+func (h *Handler) render(ctx context.Context, id notion.Id) ([]byte, error) {
+	// pages and databases are kept in memory, so every request needs a new getter
+	cli := cache.NewGetter(h.cli, h.cache)
+
+	c := n_goldmark.NewConverter(cli, append(h.opts,
+		n_goldmark.WithLinkResolver(n_goldmark.IDLinks("/")),
+		n_goldmark.WithAssetStore(assetStore{h.store}))...)
+
+	var (
+		title  string
+		doc    *ast.Document
+		source []byte
+	)
+
+	p, err := cli.GetNotionPage(ctx, id)
+
+	switch {
+	case n_goldmark.IsNotFound(err):
+		var db *notion.Database
+		if db, err = cli.GetNotionDatabase(ctx, id); err != nil {
+			return nil, err
+		}
+
+		title = db.Title.Content()
+		doc, source, err = c.Database(ctx, id)
+	case err != nil:
+		return nil, err
+	default:
+		title = p.Title()
+		doc, source, err = c.Page(ctx, id)
+	}
+
+	// assets that could not be downloaded link to Notion
+	var assetErrs n_goldmark.AssetErrors
+	if err != nil && !errors.As(err, &assetErrs) {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	if err := h.renderer.Render(body, source, doc); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := page.Execute(buf, pageData{
+		ID:    string(id),
+		Title: title,
+		Body:  template.HTML(body.String()),
+	}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// assetStore stores the assets in the asset store and links to them at AssetsPrefix.
+type assetStore struct{ store n_goldmark.AssetStore }
+
+// Store implements n_goldmark.AssetStore.
+func (s assetStore) Store(ctx context.Context, dir, rawURL string) (string, error) {
+	p, err := s.store.Store(ctx, dir, rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(AssetsPrefix, filepath.ToSlash(p)), nil
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8"/>
+<title>{{.Title}}</title>
+</head>
+<body>
+<article id="{{.ID}}" class="page sans">
+{{.Body}}
+</article>
+</body>
+</html>
+`))
+
+type pageData struct {
+	ID    string
+	Title string
+	Body  template.HTML
+}
+
+// parseID returns the ID in s, which may have dashes or not.
+func parseID(s string) (notion.Id, bool) {
+	s = strings.ReplaceAll(s, "-", "")
+	if len(s) != 32 {
+		return "", false
+	}
+
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return "", false
+		}
+	}
+
+	return notion.Id(strings.ToLower(s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:])), true
+}
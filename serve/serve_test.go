@@ -0,0 +1,133 @@
+package serve_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/faetools/go-notion/pkg/fake"
+	"github.com/faetools/go-notion/pkg/notion"
+	n_goldmark "github.com/faetools/notion-to-goldmark/goldmark"
+	"github.com/faetools/notion-to-goldmark/serve"
+	"github.com/stretchr/testify/assert"
+)
+
+// converterOptions are needed because the fake client does not contain all the blocks of the example page.
+var converterOptions = serve.WithConverterOptions(
+	n_goldmark.WithMaxBlocks(57),
+	n_goldmark.WithUnsupportedPolicy(n_goldmark.UnsupportedSkip),
+)
+
+// the files are not actually downloaded
+var httpClient = &http.Client{Transport: roundTripper(func(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("asset"))}, nil
+})}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return rt(req) }
+
+// countingGetter counts how often the children of blocks are fetched.
+type countingGetter struct {
+	notion.Getter
+
+	mu     sync.Mutex
+	blocks int
+}
+
+func (g *countingGetter) GetAllBlocks(ctx context.Context, id notion.Id) (notion.Blocks, error) {
+	g.mu.Lock()
+	g.blocks++
+	g.mu.Unlock()
+
+	return g.Getter.GetAllBlocks(ctx, id)
+}
+
+func get(t *testing.T, srv *httptest.Server, p string) (int, string) {
+	t.Helper()
+
+	resp, err := srv.Client().Get(srv.URL + p)
+	if !assert.NoError(t, err) {
+		return 0, ""
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, string(b)
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	cli, _, err := fake.NewClient()
+	assert.NoError(t, err)
+
+	g := &countingGetter{Getter: cli}
+
+	srv := httptest.NewServer(serve.NewHandler(g, converterOptions, serve.WithHTTPClient(httpClient)))
+	defer srv.Close()
+
+	t.Run("page", func(t *testing.T) {
+		status, body := get(t, srv, "/96245c8f178444a482ad1941127c3ec3")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "<title>Example Page</title>")
+
+		// child pages link to their routes
+		assert.Contains(t, body, `href="/2633808e7e364f4e972accd2d3c49004"`)
+
+		// the assets are served
+		srcs := regexp.MustCompile(`src="(/assets/[^"]+)"`).FindAllStringSubmatch(body, -1)
+		assert.NotEmpty(t, srcs)
+
+		for _, src := range srcs {
+			status, asset := get(t, srv, src[1])
+			assert.Equal(t, http.StatusOK, status, src[1])
+			assert.Equal(t, "asset", asset, src[1])
+		}
+	})
+
+	t.Run("cached children", func(t *testing.T) {
+		get(t, srv, "/2633808e-7e36-4f4e-972a-ccd2d3c49004")
+
+		g.mu.Lock()
+		before := g.blocks
+		g.mu.Unlock()
+
+		status, body := get(t, srv, "/2633808e-7e36-4f4e-972a-ccd2d3c49004")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "with some content")
+
+		g.mu.Lock()
+		assert.Equal(t, before, g.blocks)
+		g.mu.Unlock()
+	})
+
+	t.Run("database", func(t *testing.T) {
+		status, body := get(t, srv, "/7a3c647e4c1e4c27bf1dcfb0105e55ce")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "<title>My Child Database</title>")
+		assert.Contains(t, body, `href="/20c5eed259ea476eaf69da0ae68a084d"`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, p := range []string{"/", "/favicon.ico", "/00000000000000000000000000000000", "/assets/missing.png"} {
+			status, _ := get(t, srv, p)
+			assert.Equal(t, http.StatusNotFound, status, p)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, err := srv.Client().Post(srv.URL+"/96245c8f178444a482ad1941127c3ec3", "text/plain", nil)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		}
+	})
+}